@@ -17,12 +17,15 @@ import (
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/endpoints"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/service"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/transport"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/airport"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flight"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/airasia"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/batikair"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/garuda"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/lionair"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/logger"
 	"github.com/redis/go-redis/v9"
 )
@@ -113,12 +116,33 @@ func makeEndpoints(ctx context.Context, cfg *config.Config) endpoints.Endpoints
 		panic(err)
 	}
 
+	// init airport reference data before the providers use it
+	if cfg.Airport.DataPath != "" {
+		directory, err := airport.LoadFile(cfg.Airport.DataPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to load airport data", slog.String("error", err.Error()))
+			panic(err)
+		}
+		airport.Default = directory
+	}
+
+	// init exchange rates, the embedded rates are used for offline use
+	if cfg.Currency.RatesPath != "" {
+		rates, err := currency.LoadFile(cfg.Currency.RatesPath)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to load exchange rates", slog.String("error", err.Error()))
+			panic(err)
+		}
+		currency.Default = rates
+	}
+
 	// init factory
 	flightProviderFactory := initFlightProviderFactory(cfg, redisClient)
 
 	// init service endpoint
 	return endpoints.Endpoints{
 		AggregatorEndpoint: makeAggregatorEndpoint(flightProviderFactory, redisClient, cfg),
+		AirportEndpoint:    endpoints.MakeAirportEndpoint(service.NewAirportService(airport.Default)),
 	}
 }
 
@@ -126,40 +150,107 @@ func makeEndpoints(ctx context.Context, cfg *config.Config) endpoints.Endpoints
 func initFlightProviderFactory(cfg *config.Config, redisClient *redis.Client) *flightprovider.FlightProviderFactory {
 
 	limiter := redis_rate.NewLimiter(redisClient)
+	httpClient := providerutils.NewHTTPClient(cfg.Providers.MaxIdleConnsPerHost)
+	backoff := flightprovider.Backoff{
+		BaseDelay:  cfg.Providers.Retry.BaseDelay,
+		MaxDelay:   cfg.Providers.Retry.MaxDelay,
+		Multiplier: cfg.Providers.Retry.Multiplier,
+		Jitter:     cfg.Providers.Retry.Jitter,
+	}
 
 	factory := flightprovider.NewFlightProviderFactory()
-	factory.AddProvider(lionair.ProviderName, lionair.NewProvider(flightprovider.FlightProviderConfig{
-		SearchAPIURL: cfg.Providers.LionAirProvider.SearchAPIURL,
-		Timeout:      cfg.Providers.LionAirProvider.Timeout,
-		MaxRetries:   cfg.Providers.LionAirProvider.MaxRetries,
-		RateLimitRPS: cfg.Providers.LionAirProvider.RateLimitRPS,
-		Limiter:      limiter,
-	}))
-	factory.AddProvider(batikair.ProviderName, batikair.NewProvider(flightprovider.FlightProviderConfig{
-		SearchAPIURL: cfg.Providers.BatikAirProvider.SearchAPIURL,
-		Timeout:      cfg.Providers.BatikAirProvider.Timeout,
-		MaxRetries:   cfg.Providers.BatikAirProvider.MaxRetries,
-		RateLimitRPS: cfg.Providers.BatikAirProvider.RateLimitRPS,
-		Limiter:      limiter,
-	}))
-	factory.AddProvider(airasia.ProviderName, airasia.NewProvider(flightprovider.FlightProviderConfig{
-		SearchAPIURL: cfg.Providers.AirAsiaProvider.SearchAPIURL,
-		Timeout:      cfg.Providers.AirAsiaProvider.Timeout,
-		MaxRetries:   cfg.Providers.AirAsiaProvider.MaxRetries,
-		RateLimitRPS: cfg.Providers.AirAsiaProvider.RateLimitRPS,
-		Limiter:      limiter,
-	}))
-	factory.AddProvider(garuda.ProviderName, garuda.NewProvider(flightprovider.FlightProviderConfig{
-		SearchAPIURL: cfg.Providers.GarudaProvider.SearchAPIURL,
-		Timeout:      cfg.Providers.GarudaProvider.Timeout,
-		MaxRetries:   cfg.Providers.GarudaProvider.MaxRetries,
-		RateLimitRPS: cfg.Providers.GarudaProvider.RateLimitRPS,
-		Limiter:      limiter,
-	}))
+	if cfg.Providers.Hedge.Enabled {
+		factory.WithHedging(flightprovider.HedgeConfig{
+			Percentile: cfg.Providers.Hedge.Percentile,
+			MinSamples: cfg.Providers.Hedge.MinSamples,
+			Window:     cfg.Providers.Hedge.Window,
+		})
+	}
+	if cfg.Providers.Breaker.FailureThreshold > 0 {
+		factory.WithCircuitBreaker(flightprovider.CircuitBreakerConfig{
+			FailureThreshold: cfg.Providers.Breaker.FailureThreshold,
+			OpenTimeout:      cfg.Providers.Breaker.OpenTimeout,
+			HalfOpenMaxCalls: cfg.Providers.Breaker.HalfOpenMaxCalls,
+			SuccessThreshold: cfg.Providers.Breaker.SuccessThreshold,
+		})
+	}
+	factory.AddProvider(lionair.ProviderName, mustProvider(lionair.NewProvider(flightprovider.FlightProviderConfig{
+		Mode:             cfg.Providers.LionAirProvider.Mode,
+		SearchAPIURL:     cfg.Providers.LionAirProvider.SearchAPIURL,
+		APIKey:           cfg.Providers.LionAirProvider.APIKey,
+		Timeout:          cfg.Providers.LionAirProvider.Timeout,
+		MaxRetries:       cfg.Providers.LionAirProvider.MaxRetries,
+		Backoff:          backoff,
+		RetryBudget:      newRetryBudget(cfg),
+		RateLimitRPS:     cfg.Providers.LionAirProvider.RateLimitRPS,
+		Limiter:          limiter,
+		HTTPClient:       httpClient,
+		MaxResponseBytes: cfg.Providers.MaxResponseBytes,
+	})))
+	factory.AddProvider(batikair.ProviderName, mustProvider(batikair.NewProvider(flightprovider.FlightProviderConfig{
+		Mode:             cfg.Providers.BatikAirProvider.Mode,
+		SearchAPIURL:     cfg.Providers.BatikAirProvider.SearchAPIURL,
+		APIKey:           cfg.Providers.BatikAirProvider.APIKey,
+		Timeout:          cfg.Providers.BatikAirProvider.Timeout,
+		MaxRetries:       cfg.Providers.BatikAirProvider.MaxRetries,
+		Backoff:          backoff,
+		RetryBudget:      newRetryBudget(cfg),
+		RateLimitRPS:     cfg.Providers.BatikAirProvider.RateLimitRPS,
+		Limiter:          limiter,
+		HTTPClient:       httpClient,
+		MaxResponseBytes: cfg.Providers.MaxResponseBytes,
+	})))
+	factory.AddProvider(airasia.ProviderName, mustProvider(airasia.NewProvider(flightprovider.FlightProviderConfig{
+		Mode:             cfg.Providers.AirAsiaProvider.Mode,
+		SearchAPIURL:     cfg.Providers.AirAsiaProvider.SearchAPIURL,
+		APIKey:           cfg.Providers.AirAsiaProvider.APIKey,
+		Timeout:          cfg.Providers.AirAsiaProvider.Timeout,
+		MaxRetries:       cfg.Providers.AirAsiaProvider.MaxRetries,
+		Backoff:          backoff,
+		RetryBudget:      newRetryBudget(cfg),
+		RateLimitRPS:     cfg.Providers.AirAsiaProvider.RateLimitRPS,
+		Limiter:          limiter,
+		HTTPClient:       httpClient,
+		MaxResponseBytes: cfg.Providers.MaxResponseBytes,
+	})))
+	factory.AddProvider(garuda.ProviderName, mustProvider(garuda.NewProvider(flightprovider.FlightProviderConfig{
+		Mode:             cfg.Providers.GarudaProvider.Mode,
+		SearchAPIURL:     cfg.Providers.GarudaProvider.SearchAPIURL,
+		APIKey:           cfg.Providers.GarudaProvider.APIKey,
+		Timeout:          cfg.Providers.GarudaProvider.Timeout,
+		MaxRetries:       cfg.Providers.GarudaProvider.MaxRetries,
+		Backoff:          backoff,
+		RetryBudget:      newRetryBudget(cfg),
+		RateLimitRPS:     cfg.Providers.GarudaProvider.RateLimitRPS,
+		Limiter:          limiter,
+		HTTPClient:       httpClient,
+		MaxResponseBytes: cfg.Providers.MaxResponseBytes,
+	})))
 
 	return factory
 }
 
+// mustProvider panics when a provider can't be created, the config is validated on load
+// so it only fails on a misconfiguration at startup
+func mustProvider[T flightprovider.FlightProvider](provider T, err error) T {
+	if err != nil {
+		slog.Error("failed to create flight provider", slog.String("error", err.Error()))
+		panic(err)
+	}
+
+	return provider
+}
+
+// newRetryBudget returns a retry budget for a provider, no budget when the ratio isn't set
+func newRetryBudget(cfg *config.Config) *flightprovider.RetryBudget {
+	if cfg.Providers.Retry.BudgetRatio <= 0 {
+		return nil
+	}
+
+	return flightprovider.NewRetryBudget(cfg.Providers.Retry.BudgetRatio,
+		cfg.Providers.Retry.BudgetCapacity)
+}
+
 func makeAggregatorEndpoint(factory *flightprovider.FlightProviderFactory,
 	redisClient *redis.Client, cfg *config.Config) endpoints.AggregatorEndpoint {
 
@@ -168,7 +259,10 @@ func makeAggregatorEndpoint(factory *flightprovider.FlightProviderFactory,
 
 	// service
 	aggregatorService := service.NewAggregatorService(factory, flightCache,
-		cfg.Providers.CacheExpiration, cfg.Providers.LockTimeout)
+		cfg.Providers.CacheExpiration, cfg.Providers.LockTimeout, currency.Default,
+		cfg.Providers.SearchDeadline, flightCache, cfg.Providers.SessionExpiration,
+		cfg.Providers.CursorExpiration,
+		flight.FareRatios{Child: cfg.Fare.ChildRatio, Infant: cfg.Fare.InfantRatio})
 
 	// endpoint
 	return endpoints.MakeAggregatorEndpoint(aggregatorService)
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Behavior is the simulated behavior of an airline API, the rates are between 0 and 1.
+// requests without the APIKey are rejected when it is set
+type Behavior struct {
+	APIKey        string
+	MinLatency    time.Duration
+	MaxLatency    time.Duration
+	ErrorRate     float64
+	RateLimitRate float64
+	MalformedRate float64
+}
+
+// Airline describes how a mock airline serves its fixture, the paths are the
+// keys to the flight list in the fixture and to the fields of a flight
+type Airline struct {
+	Name     string
+	Prefix   string
+	Method   string
+	Path     string
+	Fixture  string
+	Behavior Behavior
+
+	flightsPath     []string
+	originPath      []string
+	destinationPath []string
+	departurePath   []string
+	params          searchParams
+	auth            authHeader
+
+	response map[string]any
+}
+
+// searchParams are the names of the origin, destination and date params of the request,
+// read from the JSON body when fromBody is set and from the query otherwise
+type searchParams struct {
+	origin      string
+	destination string
+	date        string
+	fromBody    bool
+}
+
+// authHeader is the header the airline reads the api key from, the key follows the scheme
+type authHeader struct {
+	name   string
+	scheme string
+}
+
+type searchQuery struct {
+	origin      string
+	destination string
+	date        string
+}
+
+// airlines are the mock airlines, routes match the requests of the providers
+// in internal/pkg/flightprovider
+func airlines() []*Airline {
+	return []*Airline{
+		{
+			Name:            "GARUDA",
+			Prefix:          "/garuda",
+			Method:          http.MethodGet,
+			Path:            "/v1/flights/search",
+			Fixture:         "garuda_indonesia_search_response.json",
+			flightsPath:     []string{"flights"},
+			originPath:      []string{"departure", "airport"},
+			destinationPath: []string{"arrival", "airport"},
+			departurePath:   []string{"departure", "time"},
+			params:          searchParams{origin: "origin", destination: "destination", date: "departure_date"},
+			auth:            authHeader{name: "Authorization", scheme: "Bearer "},
+		},
+		{
+			Name:            "LION_AIR",
+			Prefix:          "/lionair",
+			Method:          http.MethodGet,
+			Path:            "/api/flights/search",
+			Fixture:         "lion_air_search_response.json",
+			flightsPath:     []string{"data", "available_flights"},
+			originPath:      []string{"route", "from", "code"},
+			destinationPath: []string{"route", "to", "code"},
+			departurePath:   []string{"schedule", "departure"},
+			params:          searchParams{origin: "from", destination: "to", date: "date"},
+			auth:            authHeader{name: "X-Api-Key"},
+		},
+		{
+			Name:            "BATIK_AIR",
+			Prefix:          "/batikair",
+			Method:          http.MethodPost,
+			Path:            "/api/v1/search",
+			Fixture:         "batik_air_search_response.json",
+			flightsPath:     []string{"results"},
+			originPath:      []string{"origin"},
+			destinationPath: []string{"destination"},
+			departurePath:   []string{"departureDateTime"},
+			params: searchParams{origin: "origin", destination: "destination", date: "departureDate",
+				fromBody: true},
+			auth: authHeader{name: "X-Api-Key"},
+		},
+		{
+			Name:            "AIRASIA",
+			Prefix:          "/airasia",
+			Method:          http.MethodGet,
+			Path:            "/flights/search",
+			Fixture:         "airasia_search_response.json",
+			flightsPath:     []string{"flights"},
+			originPath:      []string{"from_airport"},
+			destinationPath: []string{"to_airport"},
+			departurePath:   []string{"depart_time"},
+			params:          searchParams{origin: "origin", destination: "destination", date: "depart_date"},
+			auth:            authHeader{name: "apikey"},
+		},
+	}
+}
+
+// load reads the fixture of the airline from dir
+func (a *Airline) load(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, a.Fixture))
+	if err != nil {
+		return fmt.Errorf("failed to read fixture: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &a.response); err != nil {
+		return fmt.Errorf("failed to decode fixture: %w", err)
+	}
+
+	return nil
+}
+
+// ServeHTTP simulates the latency first, rejects a request without the api key, then a rate limit,
+// an error or a malformed payload by their rates, otherwise it responds the fixture flights matching the search
+func (a *Airline) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-time.After(a.latency()):
+	case <-r.Context().Done():
+		return
+	}
+
+	if !a.authorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid api key"})
+		return
+	}
+
+	switch roll := rand.Float64(); {
+	case roll < a.Behavior.RateLimitRate:
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "rate limit exceeded"})
+		return
+	case roll < a.Behavior.RateLimitRate+a.Behavior.ErrorRate:
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal server error"})
+		return
+	}
+
+	query, err := a.searchQuery(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	body, err := json.Marshal(a.search(query))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+
+	// cut the payload in half so it can't be decoded
+	if rand.Float64() < a.Behavior.MalformedRate {
+		body = body[:len(body)/2]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// authorized reports whether the request carries the api key of the airline, any request is
+// authorized when no key is set
+func (a *Airline) authorized(r *http.Request) bool {
+	if a.Behavior.APIKey == "" {
+		return true
+	}
+
+	return r.Header.Get(a.auth.name) == a.auth.scheme+a.Behavior.APIKey
+}
+
+func (a *Airline) latency() time.Duration {
+	if a.Behavior.MaxLatency <= a.Behavior.MinLatency {
+		return a.Behavior.MinLatency
+	}
+
+	return a.Behavior.MinLatency +
+		time.Duration(rand.Int63n(int64(a.Behavior.MaxLatency-a.Behavior.MinLatency)+1))
+}
+
+func (a *Airline) searchQuery(r *http.Request) (searchQuery, error) {
+	if !a.params.fromBody {
+		values := r.URL.Query()
+		return searchQuery{
+			origin:      values.Get(a.params.origin),
+			destination: values.Get(a.params.destination),
+			date:        values.Get(a.params.date),
+		}, nil
+	}
+
+	var body map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return searchQuery{}, fmt.Errorf("invalid request body: %w", err)
+	}
+
+	return searchQuery{
+		origin:      stringAt(body, a.params.origin),
+		destination: stringAt(body, a.params.destination),
+		date:        stringAt(body, a.params.date),
+	}, nil
+}
+
+// search returns the fixture with only the flights matching the query, an empty param matches
+// every flight. origin and destination list the airports of the search separated by commas,
+// the cabins are left to the providers to filter like the rest of the search
+func (a *Airline) search(query searchQuery) map[string]any {
+	flights, _ := valueAt(a.response, a.flightsPath).([]any)
+
+	matched := make([]any, 0, len(flights))
+	for _, f := range flights {
+		flight, ok := f.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if query.origin != "" && !containsCode(query.origin, stringAt(flight, a.originPath...)) {
+			continue
+		}
+
+		if query.destination != "" && !containsCode(query.destination, stringAt(flight, a.destinationPath...)) {
+			continue
+		}
+
+		if query.date != "" && !strings.HasPrefix(stringAt(flight, a.departurePath...), query.date) {
+			continue
+		}
+
+		matched = append(matched, flight)
+	}
+
+	return withValue(a.response, a.flightsPath, matched)
+}
+
+// containsCode reports whether the comma separated codes contain the code
+func containsCode(codes, code string) bool {
+	for _, c := range strings.Split(codes, ",") {
+		if strings.EqualFold(strings.TrimSpace(c), code) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func valueAt(doc map[string]any, path []string) any {
+	var value any = doc
+	for _, key := range path {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = m[key]
+	}
+
+	return value
+}
+
+func stringAt(doc map[string]any, path ...string) string {
+	s, _ := valueAt(doc, path).(string)
+	return s
+}
+
+// withValue returns a copy of doc with the value set at path, the fixture itself is left as is
+func withValue(doc map[string]any, path []string, value any) map[string]any {
+	result := make(map[string]any, len(doc))
+	for k, v := range doc {
+		result[k] = v
+	}
+
+	if len(path) == 1 {
+		result[path[0]] = value
+		return result
+	}
+
+	child, _ := doc[path[0]].(map[string]any)
+	result[path[0]] = withValue(child, path[1:], value)
+
+	return result
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,176 @@
+//go:build unit
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// mockAirline returns the named airline with its fixture loaded and no latency
+func mockAirline(t *testing.T, name string, behavior Behavior) *Airline {
+	t.Helper()
+
+	for _, airline := range airlines() {
+		if airline.Name != name {
+			continue
+		}
+
+		if err := airline.load("../../tests/mockprovider"); err != nil {
+			t.Fatalf("failed to load airline %s: %v", name, err)
+		}
+		airline.Behavior = behavior
+
+		return airline
+	}
+
+	t.Fatalf("unknown airline %s", name)
+	return nil
+}
+
+// flightNumbers reads the flight numbers of the response at the flights path of the airline
+func flightNumbers(t *testing.T, airline *Airline, body []byte, key string) []string {
+	t.Helper()
+
+	var response map[string]any
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	flights, _ := valueAt(response, airline.flightsPath).([]any)
+	numbers := make([]string, 0, len(flights))
+	for _, f := range flights {
+		numbers = append(numbers, stringAt(f.(map[string]any), key))
+	}
+
+	return numbers
+}
+
+func TestAirline_ServeHTTP_Search_Closure(t *testing.T) {
+	search := func(name, key string, newRequest func() *http.Request,
+		want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			airline := mockAirline(t, name, Behavior{})
+
+			rec := httptest.NewRecorder()
+			airline.ServeHTTP(rec, newRequest())
+
+			if diff := cmp.Diff(http.StatusOK, rec.Code); diff != "" {
+				t.Fatalf("ServeHTTP status mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(want, flightNumbers(t, airline, rec.Body.Bytes(), key)); diff != "" {
+				t.Fatalf("ServeHTTP flights mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	garudaSearch := func(query string) func() *http.Request {
+		return func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/garuda/v1/flights/search?"+query, nil)
+		}
+	}
+
+	t.Run("matches_route", search("GARUDA", "flight_id",
+		garudaSearch("origin=CGK&destination=SUB&departure_date=2025-12-15"),
+		[]string{"GA315"}))
+
+	t.Run("empty_params_match_every_flight", search("GARUDA", "flight_id",
+		garudaSearch(""),
+		[]string{"GA400", "GA410", "GA315"}))
+
+	t.Run("comma_list_matches_any_airport", search("GARUDA", "flight_id",
+		garudaSearch("origin=HLP,cgk&destination=DPS,SUB"),
+		[]string{"GA400", "GA410", "GA315"}))
+
+	t.Run("other_date_matches_nothing", search("GARUDA", "flight_id",
+		garudaSearch("origin=CGK&destination=DPS&departure_date=2025-12-16"),
+		[]string{}))
+
+	t.Run("other_airport_matches_nothing", search("GARUDA", "flight_id",
+		garudaSearch("origin=HLP&destination=DPS"),
+		[]string{}))
+
+	t.Run("reads_params_from_body", search("BATIK_AIR", "flightNumber",
+		func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/batikair/api/v1/search", strings.NewReader(
+				`{"origin":"CGK","destination":"DPS","departureDate":"2025-12-15"}`))
+		},
+		[]string{"ID6514", "ID6520", "ID7042"}))
+}
+
+func TestAirline_ServeHTTP_Behavior_Closure(t *testing.T) {
+	serve := func(name string, behavior Behavior, newRequest func() *http.Request,
+		wantStatus int, wantDecodable bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			airline := mockAirline(t, name, behavior)
+
+			rec := httptest.NewRecorder()
+			airline.ServeHTTP(rec, newRequest())
+
+			if diff := cmp.Diff(wantStatus, rec.Code); diff != "" {
+				t.Fatalf("ServeHTTP status mismatch (-want +got):\n%s", diff)
+			}
+
+			var body map[string]any
+			decodable := json.Unmarshal(rec.Body.Bytes(), &body) == nil
+			if diff := cmp.Diff(wantDecodable, decodable); diff != "" {
+				t.Fatalf("ServeHTTP decodable body mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	request := func(header, value string) func() *http.Request {
+		return func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/garuda/v1/flights/search?origin=CGK", nil)
+			if header != "" {
+				r.Header.Set(header, value)
+			}
+			return r
+		}
+	}
+
+	t.Run("injected_error", serve("GARUDA", Behavior{ErrorRate: 1},
+		request("", ""), http.StatusInternalServerError, true))
+
+	t.Run("injected_rate_limit", serve("GARUDA", Behavior{RateLimitRate: 1},
+		request("", ""), http.StatusTooManyRequests, true))
+
+	t.Run("injected_malformed_payload", serve("GARUDA", Behavior{MalformedRate: 1},
+		request("", ""), http.StatusOK, false))
+
+	t.Run("invalid_body", serve("BATIK_AIR", Behavior{},
+		func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/batikair/api/v1/search", strings.NewReader("{"))
+		},
+		http.StatusBadRequest, true))
+
+	t.Run("missing_api_key_is_rejected", serve("GARUDA", Behavior{APIKey: "secret"},
+		request("", ""), http.StatusUnauthorized, true))
+
+	t.Run("wrong_api_key_is_rejected", serve("GARUDA", Behavior{APIKey: "secret"},
+		request("Authorization", "Bearer other"), http.StatusUnauthorized, true))
+
+	t.Run("key_without_scheme_is_rejected", serve("GARUDA", Behavior{APIKey: "secret"},
+		request("Authorization", "secret"), http.StatusUnauthorized, true))
+
+	t.Run("bearer_api_key_is_accepted", serve("GARUDA", Behavior{APIKey: "secret"},
+		request("Authorization", "Bearer secret"), http.StatusOK, true))
+
+	t.Run("api_key_header_is_accepted", serve("AIRASIA", Behavior{APIKey: "secret"},
+		func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/airasia/flights/search?origin=CGK", nil)
+			r.Header.Set("apikey", "secret")
+			return r
+		},
+		http.StatusOK, true))
+
+	t.Run("auth_is_checked_before_injected_failures", serve("GARUDA",
+		Behavior{APIKey: "secret", ErrorRate: 1},
+		request("", ""), http.StatusUnauthorized, true))
+}
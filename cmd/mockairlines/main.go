@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/spf13/viper"
+)
+
+// default behavior of the airlines, the same as the file mode of the providers used to simulate
+var defaultBehaviors = map[string]Behavior{
+	"GARUDA":    {MinLatency: 100 * time.Millisecond, MaxLatency: 300 * time.Millisecond},
+	"LION_AIR":  {MinLatency: 100 * time.Millisecond, MaxLatency: 200 * time.Millisecond},
+	"BATIK_AIR": {MinLatency: 200 * time.Millisecond, MaxLatency: 400 * time.Millisecond},
+	"AIRASIA":   {MinLatency: 50 * time.Millisecond, MaxLatency: 150 * time.Millisecond, ErrorRate: 0.1},
+}
+
+// mockairlines serves the provider fixtures over HTTP for local development.
+// every airline is configured with MOCK_{AIRLINE}_MIN_LATENCY, MOCK_{AIRLINE}_MAX_LATENCY,
+// MOCK_{AIRLINE}_ERROR_RATE, MOCK_{AIRLINE}_RATE_LIMIT_RATE, MOCK_{AIRLINE}_MALFORMED_RATE
+// and MOCK_{AIRLINE}_API_KEY
+func main() {
+	vpr := viper.New()
+	vpr.SetDefault("MOCKAIRLINES_PORT", 9090)
+	vpr.SetDefault("MOCKAIRLINES_FIXTURES_DIR", "tests/mockprovider")
+	vpr.AutomaticEnv()
+
+	router := chi.NewRouter()
+	router.Use(middleware.Logger)
+
+	for _, airline := range airlines() {
+		airline.Behavior = behavior(vpr, airline.Name)
+		if err := airline.load(vpr.GetString("MOCKAIRLINES_FIXTURES_DIR")); err != nil {
+			slog.Error("failed to load airline", slog.String("airline", airline.Name),
+				slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+
+		router.Method(airline.Method, airline.Prefix+airline.Path, airline)
+		slog.Info("serving airline", slog.String("airline", airline.Name),
+			slog.String("route", airline.Method+" "+airline.Prefix+airline.Path),
+			slog.Any("behavior", airline.Behavior))
+	}
+
+	server := &http.Server{
+		Handler:           router,
+		Addr:              fmt.Sprintf(":%d", vpr.GetInt("MOCKAIRLINES_PORT")),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("failed to start HTTP server", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	}()
+
+	slog.Info("running mock airlines...", slog.Int("port", vpr.GetInt("MOCKAIRLINES_PORT")))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("failed to shutdown HTTP server", slog.String("error", err.Error()))
+	}
+}
+
+func behavior(vpr *viper.Viper, name string) Behavior {
+	key := func(setting string) string {
+		return fmt.Sprintf("MOCK_%s_%s", name, setting)
+	}
+
+	defaults := defaultBehaviors[name]
+	vpr.SetDefault(key("MIN_LATENCY"), defaults.MinLatency)
+	vpr.SetDefault(key("MAX_LATENCY"), defaults.MaxLatency)
+	vpr.SetDefault(key("ERROR_RATE"), defaults.ErrorRate)
+	vpr.SetDefault(key("RATE_LIMIT_RATE"), defaults.RateLimitRate)
+	vpr.SetDefault(key("MALFORMED_RATE"), defaults.MalformedRate)
+
+	return Behavior{
+		APIKey:        vpr.GetString(key("API_KEY")),
+		MinLatency:    vpr.GetDuration(key("MIN_LATENCY")),
+		MaxLatency:    vpr.GetDuration(key("MAX_LATENCY")),
+		ErrorRate:     vpr.GetFloat64(key("ERROR_RATE")),
+		RateLimitRate: vpr.GetFloat64(key("RATE_LIMIT_RATE")),
+		MalformedRate: vpr.GetFloat64(key("MALFORMED_RATE")),
+	}
+}
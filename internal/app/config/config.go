@@ -22,6 +22,9 @@ type Config struct {
 	HTTP      HTTP       `mapstructure:",squash"`
 	Providers Provider   `mapstructure:",squash"`
 	Redis     Redis      `mapstructure:",squash"`
+	Airport   Airport    `mapstructure:",squash"`
+	Currency  Currency   `mapstructure:",squash"`
+	Fare      Fare       `mapstructure:",squash"`
 }
 type DB struct {
 	DSN                   string        `mapstructure:"DB_DSN"`
@@ -43,35 +46,93 @@ type Redis struct {
 	Timeout  time.Duration `mapstructure:"REDIS_TIMEOUT"`
 }
 
-// Provider holds the provider configuration. url will route to mock provider
+// Airport holds the airport reference data configuration.
+// the embedded dataset is used when the data path is empty
+type Airport struct {
+	DataPath string `mapstructure:"AIRPORT_DATA_PATH"`
+}
+
+// Currency holds the exchange rate configuration.
+// the embedded rates are used when the rates path is empty
+type Currency struct {
+	RatesPath string `mapstructure:"CURRENCY_RATES_PATH"`
+}
+
+// Fare holds the ratios of the adult fare charged for children and infants,
+// the providers only price adults. the usual domestic ratios are used when they are 0
+type Fare struct {
+	ChildRatio  float64 `mapstructure:"FARE_CHILD_RATIO"`
+	InfantRatio float64 `mapstructure:"FARE_INFANT_RATIO"`
+}
+
+// Provider holds the provider configuration. in file mode the url is a mock response file,
+// in http mode it is the base url of the provider API
 type LionAirProvider struct {
+	Mode         string        `mapstructure:"LION_AIR_PROVIDER_MODE"`
 	SearchAPIURL string        `mapstructure:"LION_AIR_PROVIDER_SEARCH_API_URL"`
+	APIKey       string        `mapstructure:"LION_AIR_PROVIDER_API_KEY"`
 	Timeout      time.Duration `mapstructure:"LION_AIR_PROVIDER_TIMEOUT"`
 	MaxRetries   int           `mapstructure:"LION_AIR_PROVIDER_MAX_RETRIES"`
 	RateLimitRPS int           `mapstructure:"LION_AIR_PROVIDER_RATE_LIMIT"`
 }
 
 type BatikAirProvider struct {
+	Mode         string        `mapstructure:"BATIK_AIR_PROVIDER_MODE"`
 	SearchAPIURL string        `mapstructure:"BATIK_AIR_PROVIDER_SEARCH_API_URL"`
+	APIKey       string        `mapstructure:"BATIK_AIR_PROVIDER_API_KEY"`
 	Timeout      time.Duration `mapstructure:"BATIK_AIR_PROVIDER_TIMEOUT"`
 	MaxRetries   int           `mapstructure:"BATIK_AIR_PROVIDER_MAX_RETRIES"`
 	RateLimitRPS int           `mapstructure:"BATIK_AIR_PROVIDER_RATE_LIMIT"`
 }
 
 type AirAsiaProvider struct {
+	Mode         string        `mapstructure:"AIRASIA_PROVIDER_MODE"`
 	SearchAPIURL string        `mapstructure:"AIRASIA_PROVIDER_SEARCH_API_URL"`
+	APIKey       string        `mapstructure:"AIRASIA_PROVIDER_API_KEY"`
 	Timeout      time.Duration `mapstructure:"AIRASIA_PROVIDER_TIMEOUT"`
 	MaxRetries   int           `mapstructure:"AIRASIA_PROVIDER_MAX_RETRIES"`
 	RateLimitRPS int           `mapstructure:"AIRASIA_PROVIDER_RATE_LIMIT"`
 }
 
 type GarudaProvider struct {
+	Mode         string        `mapstructure:"GARUDA_PROVIDER_MODE"`
 	SearchAPIURL string        `mapstructure:"GARUDA_PROVIDER_SEARCH_API_URL"`
+	APIKey       string        `mapstructure:"GARUDA_PROVIDER_API_KEY"`
 	Timeout      time.Duration `mapstructure:"GARUDA_PROVIDER_TIMEOUT"`
 	MaxRetries   int           `mapstructure:"GARUDA_PROVIDER_MAX_RETRIES"`
 	RateLimitRPS int           `mapstructure:"GARUDA_PROVIDER_RATE_LIMIT"`
 }
 
+// ProviderRetry holds the retry policy shared by the providers, the retries of each provider
+// are capped by a budget of BudgetRatio retries per call with BudgetCapacity retries to start with
+type ProviderRetry struct {
+	BaseDelay      time.Duration `mapstructure:"PROVIDER_RETRY_BASE_DELAY"`
+	MaxDelay       time.Duration `mapstructure:"PROVIDER_RETRY_MAX_DELAY"`
+	Multiplier     float64       `mapstructure:"PROVIDER_RETRY_MULTIPLIER"`
+	Jitter         float64       `mapstructure:"PROVIDER_RETRY_JITTER"`
+	BudgetRatio    float64       `mapstructure:"PROVIDER_RETRY_BUDGET_RATIO"`
+	BudgetCapacity int           `mapstructure:"PROVIDER_RETRY_BUDGET_CAPACITY"`
+}
+
+// ProviderBreaker holds the circuit breaker of every provider, a zero failure threshold disables it.
+// the circuit opens after FailureThreshold consecutive failures, stays open for OpenTimeout, then lets
+// HalfOpenMaxCalls probe calls through and closes after SuccessThreshold of them succeed
+type ProviderBreaker struct {
+	FailureThreshold int           `mapstructure:"PROVIDER_BREAKER_FAILURE_THRESHOLD"`
+	OpenTimeout      time.Duration `mapstructure:"PROVIDER_BREAKER_OPEN_TIMEOUT"`
+	HalfOpenMaxCalls int           `mapstructure:"PROVIDER_BREAKER_HALF_OPEN_MAX_CALLS"`
+	SuccessThreshold int           `mapstructure:"PROVIDER_BREAKER_SUCCESS_THRESHOLD"`
+}
+
+// ProviderHedge holds the hedging of every provider. a duplicate search is sent when the search is
+// slower than the Percentile latency of the last Window searches, once MinSamples are recorded
+type ProviderHedge struct {
+	Enabled    bool    `mapstructure:"PROVIDER_HEDGE_ENABLED"`
+	Percentile float64 `mapstructure:"PROVIDER_HEDGE_PERCENTILE"`
+	MinSamples int     `mapstructure:"PROVIDER_HEDGE_MIN_SAMPLES"`
+	Window     int     `mapstructure:"PROVIDER_HEDGE_WINDOW"`
+}
+
 type Provider struct {
 	LionAirProvider  LionAirProvider  `mapstructure:",squash"`
 	BatikAirProvider BatikAirProvider `mapstructure:",squash"`
@@ -79,4 +140,17 @@ type Provider struct {
 	GarudaProvider   GarudaProvider   `mapstructure:",squash"`
 	LockTimeout      time.Duration    `mapstructure:"PROVIDER_LOCK_TIMEOUT"`
 	CacheExpiration  time.Duration    `mapstructure:"PROVIDER_CACHE_EXPIRATION"`
+	Retry            ProviderRetry    `mapstructure:",squash"`
+	Breaker          ProviderBreaker  `mapstructure:",squash"`
+	Hedge            ProviderHedge    `mapstructure:",squash"`
+	// SearchDeadline is how long a search waits for the providers before returning
+	// the flights collected so far, 0 waits for every provider
+	SearchDeadline time.Duration `mapstructure:"PROVIDER_SEARCH_DEADLINE"`
+	// SessionExpiration is how long an asynchronous search session is kept after its last update
+	SessionExpiration time.Duration `mapstructure:"PROVIDER_SESSION_EXPIRATION"`
+	// CursorExpiration is how long the next pages of a paginated search can be read
+	CursorExpiration time.Duration `mapstructure:"PROVIDER_CURSOR_EXPIRATION"`
+	// MaxResponseBytes limits the provider responses, MaxIdleConnsPerHost sizes the connection pool
+	MaxResponseBytes    int64 `mapstructure:"PROVIDER_MAX_RESPONSE_BYTES"`
+	MaxIdleConnsPerHost int   `mapstructure:"PROVIDER_MAX_IDLE_CONNS_PER_HOST"`
 }
@@ -45,6 +45,11 @@ func MustInitConfig(configFile string) Config {
 		panic(err)
 	}
 
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid config", slog.String("error", err.Error()))
+		panic(err)
+	}
+
 	return cfg
 }
 
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+)
+
+// Validate checks the values that can't be caught by unmarshalling, e.g. an unknown provider mode
+func (c Config) Validate() error {
+	modes := []struct{ key, mode string }{
+		{"LION_AIR_PROVIDER_MODE", c.Providers.LionAirProvider.Mode},
+		{"BATIK_AIR_PROVIDER_MODE", c.Providers.BatikAirProvider.Mode},
+		{"AIRASIA_PROVIDER_MODE", c.Providers.AirAsiaProvider.Mode},
+		{"GARUDA_PROVIDER_MODE", c.Providers.GarudaProvider.Mode},
+	}
+
+	for _, m := range modes {
+		if err := providerutils.ValidateMode(m.mode); err != nil {
+			return fmt.Errorf("invalid %s: %w", m.key, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+)
+
+// DefaultAirportSearchLimit is the number of airports returned when limit is not set
+const DefaultAirportSearchLimit = 10
+
+type Airport struct {
+	IATA      string  `json:"iata"`
+	ICAO      string  `json:"icao"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	CityCode  string  `json:"city_code"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+// AirportSearchRequest is read from the query string, e.g. ?q=jak&limit=5
+type AirportSearchRequest struct {
+	Query string `json:"q" validate:"required,min=2"`
+	Limit int    `json:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+func (s *AirportSearchRequest) Bind(r *http.Request) error {
+	s.Query = r.URL.Query().Get("q")
+	s.Limit = DefaultAirportSearchLimit
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil {
+			return fmt.Errorf("error validate request: %w", exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    "limit must be a number",
+			})
+		}
+		s.Limit = parsed
+	}
+
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("error validate request: %w", err)
+	}
+
+	return nil
+}
+
+func (s *AirportSearchRequest) Validate() error {
+	if err := ValidateSingleError(s); err != nil {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// AirportSearchResponse is the response struct for the airport autocomplete endpoint
+type AirportSearchResponse struct {
+	Airports []Airport `json:"airports"`
+}
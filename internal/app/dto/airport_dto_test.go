@@ -0,0 +1,40 @@
+//go:build unit
+
+package dto
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAirportSearchRequest_Bind(t *testing.T) {
+	_ = InitValidator()
+
+	bindRequest := func(url string, want AirportSearchRequest, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			var got AirportSearchRequest
+			err := got.Bind(httptest.NewRequest("GET", url, nil))
+			if (err != nil) != wantErr {
+				t.Fatalf("Bind() error = %v, wantErr %v", err, wantErr)
+			}
+
+			if wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("Bind() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("default_limit", bindRequest("/api/v1/airports?q=jak",
+		AirportSearchRequest{Query: "jak", Limit: DefaultAirportSearchLimit}, false))
+	t.Run("custom_limit", bindRequest("/api/v1/airports?q=jak&limit=3",
+		AirportSearchRequest{Query: "jak", Limit: 3}, false))
+	t.Run("missing_query", bindRequest("/api/v1/airports", AirportSearchRequest{}, true))
+	t.Run("query_too_short", bindRequest("/api/v1/airports?q=j", AirportSearchRequest{}, true))
+	t.Run("invalid_limit", bindRequest("/api/v1/airports?q=jak&limit=abc", AirportSearchRequest{}, true))
+}
@@ -0,0 +1,36 @@
+package dto
+
+// canonical amenities every provider is mapped to
+const (
+	AmenityWifi          = "wifi"
+	AmenityMeal          = "meal"
+	AmenitySnack         = "snack"
+	AmenityBeverage      = "beverage"
+	AmenityPower         = "power"
+	AmenityUSB           = "usb"
+	AmenityEntertainment = "entertainment"
+	AmenityExtraLegroom  = "extra_legroom"
+)
+
+// Amenities is the canonical amenity vocabulary
+var Amenities = []string{
+	AmenityWifi,
+	AmenityMeal,
+	AmenitySnack,
+	AmenityBeverage,
+	AmenityPower,
+	AmenityUSB,
+	AmenityEntertainment,
+	AmenityExtraLegroom,
+}
+
+// IsAmenity reports whether the amenity is part of the canonical vocabulary
+func IsAmenity(amenity string) bool {
+	for _, a := range Amenities {
+		if a == amenity {
+			return true
+		}
+	}
+
+	return false
+}
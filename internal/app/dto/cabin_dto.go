@@ -0,0 +1,49 @@
+package dto
+
+// cabin classes from the lowest to the highest
+const (
+	CabinEconomy        = "economy"
+	CabinPremiumEconomy = "premium_economy"
+	CabinBusiness       = "business"
+	CabinFirst          = "first"
+)
+
+// CabinRank orders the cabin classes, a higher rank is a higher cabin
+var CabinRank = map[string]int{
+	CabinEconomy:        1,
+	CabinPremiumEconomy: 2,
+	CabinBusiness:       3,
+	CabinFirst:          4,
+}
+
+// matchCabin reports whether a flight cabin satisfies the requested cabin,
+// higher cabins only match when they are included
+func matchCabin(requested, cabin string, includeHigher bool) bool {
+	if requested == "" || cabin == requested {
+		return true
+	}
+
+	if !includeHigher {
+		return false
+	}
+
+	requestedRank, ok := CabinRank[requested]
+	if !ok {
+		return false
+	}
+
+	return CabinRank[cabin] > requestedRank
+}
+
+// SearchCabins returns the cabin classes matching the search from the lowest,
+// every cabin above the cabin class is included when higher cabins are
+func (s SearchCriteria) SearchCabins() []string {
+	cabins := []string{}
+	for _, cabin := range []string{CabinEconomy, CabinPremiumEconomy, CabinBusiness, CabinFirst} {
+		if s.CabinClass != "" && s.MatchCabin(cabin) {
+			cabins = append(cabins, cabin)
+		}
+	}
+
+	return cabins
+}
@@ -0,0 +1,57 @@
+package dto
+
+// PriceFacetBuckets is the number of buckets of the price histogram
+const PriceFacetBuckets = 5
+
+// DepartureTimeBuckets are the parts of the day of the departure time facet, in the local time
+// of the departure. the filter compares hours so a bucket ends at the last minute of its last hour
+var DepartureTimeBuckets = []DepartureTimeFacet{
+	{Name: "early_morning", Start: "00:00", End: "05:59"},
+	{Name: "morning", Start: "06:00", End: "11:59"},
+	{Name: "afternoon", Start: "12:00", End: "17:59"},
+	{Name: "evening", Start: "18:00", End: "23:59"},
+}
+
+// Facets counts the flights of every filter value before the results are filtered. every facet
+// applies the other active filters but not its own, so a selected airline still counts the others
+type Facets struct {
+	Airlines       []AirlineFacet       `json:"airlines"`
+	Stops          []StopsFacet         `json:"stops"`
+	Prices         []PriceFacet         `json:"prices"`
+	DepartureTimes []DepartureTimeFacet `json:"departure_times"`
+	// Duration is empty when no flight matches the other filters
+	Duration *DurationFacet `json:"duration,omitempty"`
+}
+
+// AirlineFacet counts the flights marketed or operated by the airline and their lowest price
+type AirlineFacet struct {
+	Airline  Airline `json:"airline"`
+	Count    int     `json:"count"`
+	MinPrice Price   `json:"min_price"`
+}
+
+type StopsFacet struct {
+	Stops int `json:"stops"`
+	Count int `json:"count"`
+}
+
+// PriceFacet counts the flights priced from Min up to Max, Max is only included in the last bucket
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int     `json:"count"`
+}
+
+// DepartureTimeFacet counts the flights departing from Start until End, e.g. 06:00 to 11:59,
+// the times can be sent as the departure_time_start and departure_time_end filters
+type DepartureTimeFacet struct {
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+	Count int    `json:"count"`
+}
+
+type DurationFacet struct {
+	Min Duration `json:"min"`
+	Max Duration `json:"max"`
+}
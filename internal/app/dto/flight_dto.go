@@ -1,47 +1,102 @@
 package dto
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/airport"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
 )
 
+// Flight is a normalized flight, Airline is the marketing carrier selling the flight number
+// and OperatingAirline is the carrier flying it, they differ on codeshare flights
 type Flight struct {
-	ID             string    `json:"id"`
-	Provider       string    `json:"provider"`
-	Airline        Airline   `json:"airline"`
-	FlightNumber   string    `json:"flight_number"`
-	Departure      Departure `json:"departure"`
-	Arrival        Arrival   `json:"arrival"`
-	Duration       Duration  `json:"duration"`
-	Stops          int       `json:"stops"`
-	Price          Price     `json:"price"`
-	AvailableSeats int       `json:"available_seats"`
-	CabinClass     string    `json:"cabin_class"`
-	Aircraft       *string   `json:"aircraft"`
-	Amenities      []string  `json:"amenities"`
-	Baggage        Baggage   `json:"baggage"`
-	Score          float64   `json:"score"`
+	ID               string    `json:"id"`
+	Provider         string    `json:"provider"`
+	Airline          Airline   `json:"airline"`
+	OperatingAirline Airline   `json:"operating_airline"`
+	FlightNumber     string    `json:"flight_number"`
+	Departure        Departure `json:"departure"`
+	Arrival          Arrival   `json:"arrival"`
+	Duration         Duration  `json:"duration"`
+	Stops            int       `json:"stops"`
+	Price            Price     `json:"price"`
+	AvailableSeats   int       `json:"available_seats"`
+	CabinClass       string    `json:"cabin_class"`
+	Aircraft         *string   `json:"aircraft"`
+	Amenities        []string  `json:"amenities"`
+	Baggage          Baggage   `json:"baggage"`
+	Score            float64   `json:"score"`
+	Direction        string    `json:"direction,omitempty"`
+	Segments         []Segment `json:"segments,omitempty"`
+	Layovers         []Layover `json:"layovers,omitempty"`
+	Offers           []Offer   `json:"offers,omitempty"`
+	Fares            *Fares    `json:"fares,omitempty"`
 }
 
+// Offer is the price and seats of a flight sold by one provider,
+// a flight sold by more than one provider lists every offer
+type Offer struct {
+	ID             string `json:"id"`
+	Provider       string `json:"provider"`
+	Price          Price  `json:"price"`
+	AvailableSeats int    `json:"available_seats"`
+	Cheapest       bool   `json:"cheapest"`
+}
+
+// direction of a flight within a round-trip search
+const (
+	DirectionOutbound = "outbound"
+	DirectionInbound  = "inbound"
+)
+
 type Airline struct {
 	Name string `json:"name"`
 	Code string `json:"code"`
 }
 
 type Departure struct {
-	Airport   string `json:"airport"`
-	City      string `json:"city"`
-	Datetime  string `json:"datetime"`
-	Timestamp int64  `json:"timestamp"`
+	Airport     string `json:"airport"`
+	AirportName string `json:"airport_name,omitempty"`
+	City        string `json:"city"`
+	Country     string `json:"country,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	Terminal    string `json:"terminal,omitempty"`
+	Datetime    string `json:"datetime"`
+	Timestamp   int64  `json:"timestamp"`
 }
 
 type Arrival struct {
-	Airport   string `json:"airport"`
-	City      string `json:"city"`
-	Datetime  string `json:"datetime"`
-	Timestamp int64  `json:"timestamp"`
+	Airport     string `json:"airport"`
+	AirportName string `json:"airport_name,omitempty"`
+	City        string `json:"city"`
+	Country     string `json:"country,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	Terminal    string `json:"terminal,omitempty"`
+	Datetime    string `json:"datetime"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+// Segment is one takeoff and landing of a connecting flight.
+// providers that only report layovers don't give the schedule between stops,
+// so only the first departure and the last arrival have a time
+type Segment struct {
+	FlightNumber string    `json:"flight_number"`
+	Departure    Departure `json:"departure"`
+	Arrival      Arrival   `json:"arrival"`
+	Duration     *Duration `json:"duration,omitempty"`
+}
+
+// Layover is the connection time spent at an airport between two segments
+type Layover struct {
+	Airport  string   `json:"airport"`
+	City     string   `json:"city"`
+	Duration Duration `json:"duration"`
 }
 
 type Duration struct {
@@ -50,27 +105,78 @@ type Duration struct {
 }
 
 type Price struct {
-	Amount    float64 `json:"amount"`
-	Currency  string  `json:"currency"`
-	Formatted string  `json:"formatted"`
+	Amount    float64        `json:"amount"`
+	Currency  string         `json:"currency"`
+	Formatted string         `json:"formatted"`
+	Breakdown *FareBreakdown `json:"breakdown,omitempty"`
+}
+
+// FareBreakdown splits the amount of a price into its components, they always add up to the amount.
+// Reported is false when the provider only gives a total and the components are derived from it
+type FareBreakdown struct {
+	BaseFare    float64 `json:"base_fare"`
+	Taxes       float64 `json:"taxes"`
+	Surcharges  float64 `json:"surcharges"`
+	ServiceFees float64 `json:"service_fees"`
+	Reported    bool    `json:"reported"`
 }
 
 type Baggage struct {
-	CarryOn string `json:"carry_on"`
-	Checked string `json:"checked"`
+	CarryOn BaggageAllowance `json:"carry_on"`
+	Checked BaggageAllowance `json:"checked"`
+}
+
+// BaggageAllowance is the free allowance of one kind of baggage, pieces and weight
+// are nil when the provider doesn't say, WeightKg is the total of every piece.
+// Description keeps the provider text
+type BaggageAllowance struct {
+	Included    bool   `json:"included"`
+	Pieces      *int   `json:"pieces,omitempty"`
+	WeightKg    *int   `json:"weight_kg,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 type SearchCriteria struct {
-	Origin        string        `json:"origin" validate:"required"`
-	Destination   string        `json:"destination" validate:"required"`
-	DepartureDate string        `json:"departure_date" validate:"required"`
-	Passengers    int           `json:"passengers" validate:"required,min=1,max=10"`
-	CabinClass    string        `json:"cabin_class" validate:"required,oneof=economy business first"`
-	SortOption    *SortOption   `json:"sort_option,omitempty"`
-	FilterOption  *FilterOption `json:"filter_option,omitempty"`
+	Origin        string `json:"origin" validate:"required"`
+	Destination   string `json:"destination" validate:"required"`
+	DepartureDate string `json:"departure_date" validate:"required"`
+	ReturnDate    string `json:"return_date,omitempty"`
+	FlexibleDays  int    `json:"flexible_days,omitempty" validate:"omitempty,min=0,max=3"`
+	// NearbyAirportsKm also searches airports within this radius of origin and destination
+	NearbyAirportsKm int `json:"nearby_airports_km,omitempty" validate:"omitempty,min=0,max=300"`
+	// Passengers is the number of adults, use the passenger types to add children and infants
+	Passengers int `json:"passengers,omitempty" validate:"omitempty,min=1,max=10"`
+	PassengerTypes
+	CabinClass string `json:"cabin_class" validate:"required,oneof=economy premium_economy business first"`
+	// IncludeHigherCabins also returns flights of cabins above the cabin class
+	IncludeHigherCabins bool `json:"include_higher_cabins,omitempty"`
+	// DisplayCurrency is the ISO 4217 code every price is converted to, default IDR
+	DisplayCurrency string `json:"display_currency,omitempty" validate:"omitempty,len=3,alpha"`
+	// Locale formats prices and durations, defaults to the Accept-Language header
+	Locale string `json:"locale,omitempty" validate:"omitempty,oneof=id-ID en-US en-SG ms-MY"`
+	// TimeoutMs overrides the search deadline, providers still searching by then are timed out
+	TimeoutMs int `json:"timeout_ms,omitempty" validate:"omitempty,min=100,max=60000"`
+	// PageSize limits the flights of a one-way search, Cursor is the next_cursor of the previous page
+	PageSize     int           `json:"page_size,omitempty" validate:"omitempty,min=1,max=100"`
+	Cursor       string        `json:"cursor,omitempty"`
+	SortOption   *SortOption   `json:"sort_option,omitempty"`
+	FilterOption *FilterOption `json:"filter_option,omitempty"`
+	// IncludeFacets also returns the facets of a one-way search
+	IncludeFacets bool `json:"include_facets,omitempty"`
 }
 
 func (s *SearchCriteria) Bind(r *http.Request) error {
+	// GET searches are read from the query string, e.g. the streaming search of an EventSource
+	if r != nil && r.Method == http.MethodGet {
+		if err := s.bindQuery(r.URL.Query()); err != nil {
+			return fmt.Errorf("error validate request: %w", err)
+		}
+	}
+
+	if s.Locale == "" && r != nil {
+		s.Locale = locale.Negotiate(r.Header.Get("Accept-Language"))
+	}
+
 	if err := s.Validate(); err != nil {
 		return fmt.Errorf("error validate request: %w", err)
 	}
@@ -86,44 +192,188 @@ func (s *SearchCriteria) Validate() error {
 		}
 	}
 
-	if s.SortOption != nil {
-		if !AllowedSortField[s.SortOption.Field] {
+	if err := validatePassengers(s.Passengers, s.PassengerTypes); err != nil {
+		return err
+	}
+
+	if s.IsRoundTrip() {
+		departureDate, err := time.Parse(DateFormat, s.DepartureDate)
+		if err != nil {
 			return exception.ApplicationError{
 				StatusCode: http.StatusBadRequest,
-				Message:    fmt.Sprintf("Invalid sort field %s", s.SortOption.Field),
+				Message:    "departure_date must be in YYYY-MM-DD format",
 			}
 		}
-	}
 
-	if s.FilterOption != nil {
-		if s.FilterOption.MinPrice != nil && s.FilterOption.MaxPrice != nil &&
-			*s.FilterOption.MaxPrice <= *s.FilterOption.MinPrice {
+		returnDate, err := time.Parse(DateFormat, s.ReturnDate)
+		if err != nil {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    "return_date must be in YYYY-MM-DD format",
+			}
+		}
+
+		if returnDate.Before(departureDate) {
 			return exception.ApplicationError{
 				StatusCode: http.StatusBadRequest,
-				Message:    "max_price must be greater than min_price",
+				Message:    "return_date must not be before departure_date",
 			}
 		}
+	}
 
-		if s.FilterOption.MinStops != nil && s.FilterOption.MaxStops != nil &&
-			*s.FilterOption.MaxStops <= *s.FilterOption.MinStops {
+	if s.IsFlexible() {
+		if s.IsRoundTrip() {
 			return exception.ApplicationError{
 				StatusCode: http.StatusBadRequest,
-				Message:    "max_stops must be greater than min_stops",
+				Message:    "flexible_days is only supported for one-way search",
 			}
 		}
 
-		if s.FilterOption.MinDurationMinutes != nil && s.FilterOption.MaxDurationMinutes != nil &&
-			*s.FilterOption.MaxDurationMinutes <= *s.FilterOption.MinDurationMinutes {
+		if _, err := time.Parse(DateFormat, s.DepartureDate); err != nil {
 			return exception.ApplicationError{
 				StatusCode: http.StatusBadRequest,
-				Message:    "max_duration_minutes must be greater than min_duration_minutes",
+				Message:    "departure_date must be in YYYY-MM-DD format",
 			}
 		}
 	}
 
+	if s.Cursor != "" && s.PageSize == 0 {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "page_size is required with cursor",
+		}
+	}
+
+	if s.IsPaginated() && (s.IsRoundTrip() || s.IsFlexible()) {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "page_size is only supported for one-way search",
+		}
+	}
+
+	if s.IncludeFacets && s.IsRoundTrip() {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "include_facets is only supported for one-way search",
+		}
+	}
+
+	if s.SortOption != nil {
+		if err := s.SortOption.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.FilterOption != nil {
+		if err := s.FilterOption.Validate(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// IsPaginated reports whether the search asks for a page of flights
+func (s SearchCriteria) IsPaginated() bool {
+	return s.PageSize > 0
+}
+
+// TargetCurrency is the currency prices are converted to before they are compared
+func (s SearchCriteria) TargetCurrency() string {
+	return targetCurrency(s.DisplayCurrency)
+}
+
+// PassengerCounts returns the passengers by type
+func (s SearchCriteria) PassengerCounts() PassengerTypes {
+	return passengerTypes(s.Passengers, s.PassengerTypes)
+}
+
+// SeatCount is the number of seats the flight must have available
+func (s SearchCriteria) SeatCount() int {
+	return s.PassengerCounts().SeatCount()
+}
+
+// MatchCabin reports whether a flight of the cabin class satisfies the search
+func (s SearchCriteria) MatchCabin(cabin string) bool {
+	return matchCabin(s.CabinClass, cabin, s.IncludeHigherCabins)
+}
+
+// Deadline returns the search deadline of the request, or fallback when it isn't set
+func (s SearchCriteria) Deadline(fallback time.Duration) time.Duration {
+	if s.TimeoutMs > 0 {
+		return time.Duration(s.TimeoutMs) * time.Millisecond
+	}
+
+	return fallback
+}
+
+// IsFlexible reports whether the search asks for a calendar around the departure date
+func (s SearchCriteria) IsFlexible() bool {
+	return s.FlexibleDays > 0
+}
+
+// FlexibleDates returns every date from flexible_days before until flexible_days
+// after the departure date, the departure date must be valid
+func (s SearchCriteria) FlexibleDates() []string {
+	departureDate, err := time.Parse(DateFormat, s.DepartureDate)
+	if err != nil {
+		return []string{s.DepartureDate}
+	}
+
+	dates := make([]string, 0, 2*s.FlexibleDays+1)
+	for day := -s.FlexibleDays; day <= s.FlexibleDays; day++ {
+		dates = append(dates, departureDate.AddDate(0, 0, day).Format(DateFormat))
+	}
+
+	return dates
+}
+
+// DateCriteria returns the exact-date search departing on date
+func (s SearchCriteria) DateCriteria(date string) SearchCriteria {
+	exact := s
+	exact.DepartureDate = date
+	exact.FlexibleDays = 0
+
+	return exact
+}
+
+// OriginAirports resolves the origin city or airport code,
+// with nearby airports when nearby_airports_km is set
+func (s SearchCriteria) OriginAirports() []string {
+	return airport.Default.Resolve(s.Origin, float64(s.NearbyAirportsKm))
+}
+
+// DestinationAirports resolves the destination city or airport code,
+// with nearby airports when nearby_airports_km is set
+func (s SearchCriteria) DestinationAirports() []string {
+	return airport.Default.Resolve(s.Destination, float64(s.NearbyAirportsKm))
+}
+
+// IsRoundTrip reports whether the search asks for a return flight
+func (s SearchCriteria) IsRoundTrip() bool {
+	return s.ReturnDate != ""
+}
+
+// OutboundCriteria returns the one-way search for the outbound leg
+func (s SearchCriteria) OutboundCriteria() SearchCriteria {
+	outbound := s
+	outbound.ReturnDate = ""
+
+	return outbound
+}
+
+// InboundCriteria returns the one-way search for the inbound leg,
+// flying back from destination to origin on the return date
+func (s SearchCriteria) InboundCriteria() SearchCriteria {
+	inbound := s
+	inbound.Origin = s.Destination
+	inbound.Destination = s.Origin
+	inbound.DepartureDate = s.ReturnDate
+	inbound.ReturnDate = ""
+
+	return inbound
+}
+
 type FilterOption struct {
 	MinPrice           *float64 `json:"min_price,omitempty" validate:"omitempty,numeric,gt=0"`
 	MaxPrice           *float64 `json:"max_price,omitempty" validate:"omitempty,numeric,gt=0"`
@@ -136,6 +386,76 @@ type FilterOption struct {
 	ArrivalTimeEnd     *string  `json:"arrival_time_end,omitempty"`
 	MinDurationMinutes *int     `json:"min_duration_minutes,omitempty" validate:"omitempty,numeric,gte=0"`
 	MaxDurationMinutes *int     `json:"max_duration_minutes,omitempty" validate:"omitempty,numeric,gte=0"`
+	MinLayoverMinutes  *int     `json:"min_layover_minutes,omitempty" validate:"omitempty,numeric,gte=0"`
+	MaxLayoverMinutes  *int     `json:"max_layover_minutes,omitempty" validate:"omitempty,numeric,gte=0"`
+	// IncludeLayoverAirports keeps flights with a layover at one of the airports,
+	// ExcludeLayoverAirports drops flights with a layover at any of the airports
+	IncludeLayoverAirports []string `json:"include_layover_airports,omitempty"`
+	ExcludeLayoverAirports []string `json:"exclude_layover_airports,omitempty"`
+	// CheckedBaggageIncluded keeps flights with free checked baggage,
+	// MinCheckedBaggageKg also needs the provider to report the weight
+	CheckedBaggageIncluded *bool `json:"checked_baggage_included,omitempty"`
+	MinCheckedBaggageKg    *int  `json:"min_checked_baggage_kg,omitempty" validate:"omitempty,numeric,gte=0"`
+	// Amenities keeps flights offering every one of the canonical amenities
+	Amenities []string `json:"amenities,omitempty"`
+}
+
+// Validate checks the filter ranges, the lower bound must be below the upper bound
+func (f *FilterOption) Validate() error {
+	if f.MinPrice != nil && f.MaxPrice != nil &&
+		*f.MaxPrice <= *f.MinPrice {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "max_price must be greater than min_price",
+		}
+	}
+
+	if f.MinStops != nil && f.MaxStops != nil &&
+		*f.MaxStops <= *f.MinStops {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "max_stops must be greater than min_stops",
+		}
+	}
+
+	if f.MinDurationMinutes != nil && f.MaxDurationMinutes != nil &&
+		*f.MaxDurationMinutes <= *f.MinDurationMinutes {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "max_duration_minutes must be greater than min_duration_minutes",
+		}
+	}
+
+	if f.MinLayoverMinutes != nil && f.MaxLayoverMinutes != nil &&
+		*f.MaxLayoverMinutes <= *f.MinLayoverMinutes {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "max_layover_minutes must be greater than min_layover_minutes",
+		}
+	}
+
+	for _, amenity := range f.Amenities {
+		if !IsAmenity(amenity) {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("amenity %s is not supported", amenity),
+			}
+		}
+	}
+
+	for _, included := range f.IncludeLayoverAirports {
+		for _, excluded := range f.ExcludeLayoverAirports {
+			if strings.EqualFold(included, excluded) {
+				return exception.ApplicationError{
+					StatusCode: http.StatusBadRequest,
+					Message: fmt.Sprintf("layover airport %s can't be both included and excluded",
+						included),
+				}
+			}
+		}
+	}
+
+	return nil
 }
 
 type SortOption struct {
@@ -143,18 +463,89 @@ type SortOption struct {
 	Order string `json:"order"`
 }
 
+func (s *SortOption) Validate() error {
+	if !AllowedSortField[s.Field] {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Invalid sort field %s", s.Field),
+		}
+	}
+
+	return nil
+}
+
 type Metadata struct {
-	TotalResults       int  `json:"total_results"`
-	ProvidersQueried   int  `json:"providers_queried"`
-	ProvidersSucceeded int  `json:"providers_succeeded"`
-	ProvidersFailed    int  `json:"providers_failed"`
-	SearchTimeMs       int  `json:"search_time_ms"`
-	CacheHit           bool `json:"cache_hit"`
+	TotalResults       int `json:"total_results"`
+	ProvidersQueried   int `json:"providers_queried"`
+	ProvidersSucceeded int `json:"providers_succeeded"`
+	ProvidersFailed    int `json:"providers_failed"`
+	// ProvidersSkipped are not called because their circuit breaker is open
+	ProvidersSkipped int `json:"providers_skipped"`
+	// ProvidersTimedOut are still searching at the search deadline, their flights are missing
+	ProvidersTimedOut int      `json:"providers_timed_out"`
+	TimedOutProviders []string `json:"timed_out_providers,omitempty"`
+	SearchTimeMs      int      `json:"search_time_ms"`
+	CacheHit          bool     `json:"cache_hit"`
+}
+
+// Itinerary is a priced combination of flights, one per leg of the trip
+type Itinerary struct {
+	ID       string   `json:"id"`
+	Legs     []Flight `json:"legs"`
+	Price    Price    `json:"price"`
+	Fares    *Fares   `json:"fares,omitempty"`
+	Duration Duration `json:"duration"`
+	Stops    int      `json:"stops"`
+	Score    float64  `json:"score"`
+}
+
+// CalendarDay summarizes the filtered flights departing on a date,
+// lowest price and fastest duration are empty when there is no flight
+type CalendarDay struct {
+	Date            string    `json:"date"`
+	LowestPrice     *Price    `json:"lowest_price,omitempty"`
+	FastestDuration *Duration `json:"fastest_duration,omitempty"`
+	TotalResults    int       `json:"total_results"`
 }
 
 // SearchFlightResponse is the response struct for the search flight endpoint
+// one-way searches return flights, round-trip searches return itineraries
+// and flexible-date searches also return the calendar around the departure date
 type SearchFlightResponse struct {
 	SearchCriteria SearchCriteria `json:"search_criteria"`
 	Metadata       Metadata       `json:"metadata"`
 	Flights        []Flight       `json:"flights"`
+	Itineraries    []Itinerary    `json:"itineraries,omitempty"`
+	Calendar       []CalendarDay  `json:"calendar,omitempty"`
+	// Facets counts the flights of every filter value, set when include_facets is requested
+	Facets *Facets `json:"facets,omitempty"`
+	// NextCursor continues a paginated search, it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// MarshalJSON always sends the flights of a one-way search, an empty search returns [].
+// round-trip searches only send the itineraries
+func (r SearchFlightResponse) MarshalJSON() ([]byte, error) {
+	type response SearchFlightResponse
+
+	if r.SearchCriteria.IsRoundTrip() {
+		return json.Marshal(struct {
+			response
+			Flights []Flight `json:"flights,omitempty"`
+		}{response: response(r)})
+	}
+
+	if r.Flights == nil {
+		r.Flights = []Flight{}
+	}
+
+	return json.Marshal(response(r))
+}
+
+func targetCurrency(displayCurrency string) string {
+	if displayCurrency == "" {
+		return currency.DefaultCurrency
+	}
+
+	return strings.ToUpper(displayCurrency)
 }
@@ -3,9 +3,14 @@
 package dto
 
 import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
 )
 
 func TestSearchCriteria_Validate(t *testing.T) {
@@ -92,6 +97,262 @@ func TestSearchCriteria_Validate(t *testing.T) {
 			MaxDurationMinutes: ptrInt(100),
 		},
 	}, true, "max_duration_minutes must be greater than min_duration_minutes"))
+
+	t.Run("invalid_layover_range", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		FilterOption: &FilterOption{
+			MinLayoverMinutes: ptrInt(240),
+			MaxLayoverMinutes: ptrInt(60),
+		},
+	}, true, "max_layover_minutes must be greater than min_layover_minutes"))
+
+	t.Run("layover_airport_included_and_excluded", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		FilterOption: &FilterOption{
+			IncludeLayoverAirports: []string{"SUB"},
+			ExcludeLayoverAirports: []string{"sub"},
+		},
+	}, true, "layover airport SUB can't be both included and excluded"))
+
+	t.Run("unsupported_amenity", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		FilterOption: &FilterOption{
+			Amenities: []string{AmenityWifi, "spa"},
+		},
+	}, true, "amenity spa is not supported"))
+
+	t.Run("valid_round_trip", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}, false, ""))
+
+	t.Run("invalid_return_date_format", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "05-01-2024",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}, true, "return_date must be in YYYY-MM-DD format"))
+
+	t.Run("return_date_before_departure_date", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-05",
+		ReturnDate:    "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}, true, "return_date must not be before departure_date"))
+
+	t.Run("flexible_days_with_return_date", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		FlexibleDays:  3,
+		Passengers:    1,
+		CabinClass:    "economy",
+	}, true, "flexible_days is only supported for one-way search"))
+
+	t.Run("flexible_days_too_wide", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		FlexibleDays:  7,
+		Passengers:    1,
+		CabinClass:    "economy",
+	}, true, "flexible_days must be 3 or less"))
+
+	t.Run("valid_premium_economy", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "premium_economy",
+	}, false, ""))
+
+	t.Run("invalid_display_currency", validateRequest(SearchCriteria{
+		Origin:          "JKT",
+		Destination:     "DPS",
+		DepartureDate:   "2024-01-01",
+		Passengers:      1,
+		CabinClass:      "economy",
+		DisplayCurrency: "SGDX",
+	}, true, "display_currency must be 3 characters in length"))
+
+	t.Run("valid_passenger_types", validateRequest(SearchCriteria{
+		Origin:         "JKT",
+		Destination:    "DPS",
+		DepartureDate:  "2024-01-01",
+		PassengerTypes: PassengerTypes{Adults: 2, Children: 1, Infants: 2},
+		CabinClass:     "economy",
+	}, false, ""))
+
+	t.Run("missing_passengers", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		CabinClass:    "economy",
+	}, true, "passengers or adults is a required field"))
+
+	t.Run("passengers_with_passenger_types", validateRequest(SearchCriteria{
+		Origin:         "JKT",
+		Destination:    "DPS",
+		DepartureDate:  "2024-01-01",
+		Passengers:     1,
+		PassengerTypes: PassengerTypes{Adults: 1},
+		CabinClass:     "economy",
+	}, true, "passengers can't be combined with adults, children and infants"))
+
+	t.Run("children_without_adult", validateRequest(SearchCriteria{
+		Origin:         "JKT",
+		Destination:    "DPS",
+		DepartureDate:  "2024-01-01",
+		PassengerTypes: PassengerTypes{Children: 2},
+		CabinClass:     "economy",
+	}, true, "adults must be 1 or more"))
+
+	t.Run("more_infants_than_adults", validateRequest(SearchCriteria{
+		Origin:         "JKT",
+		Destination:    "DPS",
+		DepartureDate:  "2024-01-01",
+		PassengerTypes: PassengerTypes{Adults: 1, Infants: 2},
+		CabinClass:     "economy",
+	}, true, "infants must not be more than adults"))
+
+	t.Run("too_many_passengers", validateRequest(SearchCriteria{
+		Origin:         "JKT",
+		Destination:    "DPS",
+		DepartureDate:  "2024-01-01",
+		PassengerTypes: PassengerTypes{Adults: 5, Children: 4, Infants: 2},
+		CabinClass:     "economy",
+	}, true, "total passengers must be 10 or less"))
+
+	t.Run("cursor_without_page_size", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		Cursor:        "cursor",
+	}, true, "page_size is required with cursor"))
+
+	t.Run("paginated_round_trip", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		Passengers:    1,
+		CabinClass:    "economy",
+		PageSize:      20,
+	}, true, "page_size is only supported for one-way search"))
+
+	t.Run("facets_round_trip", validateRequest(SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		Passengers:    1,
+		CabinClass:    "economy",
+		IncludeFacets: true,
+	}, true, "include_facets is only supported for one-way search"))
+}
+
+func TestSearchCriteria_PassengerCounts(t *testing.T) {
+	legacy := SearchCriteria{Passengers: 3}
+	if diff := cmp.Diff(PassengerTypes{Adults: 3}, legacy.PassengerCounts()); diff != "" {
+		t.Fatalf("PassengerCounts() mismatch (-want +got):\n%s", diff)
+	}
+
+	// infants sit on a lap
+	typed := SearchCriteria{PassengerTypes: PassengerTypes{Adults: 2, Children: 1, Infants: 1}}
+	if diff := cmp.Diff(3, typed.SeatCount()); diff != "" {
+		t.Fatalf("SeatCount() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSearchCriteria_MatchCabin(t *testing.T) {
+	matchRequest := func(req SearchCriteria, cabin string, want bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			if got := req.MatchCabin(cabin); got != want {
+				t.Fatalf("MatchCabin(%q) = %v, want %v", cabin, got, want)
+			}
+		}
+	}
+
+	premium := SearchCriteria{CabinClass: CabinPremiumEconomy}
+	premiumOrHigher := SearchCriteria{CabinClass: CabinPremiumEconomy, IncludeHigherCabins: true}
+
+	t.Run("same_cabin", matchRequest(premium, CabinPremiumEconomy, true))
+	t.Run("higher_cabin_excluded", matchRequest(premium, CabinBusiness, false))
+	t.Run("higher_cabin_included", matchRequest(premiumOrHigher, CabinFirst, true))
+	t.Run("lower_cabin", matchRequest(premiumOrHigher, CabinEconomy, false))
+	t.Run("unknown_cabin", matchRequest(premiumOrHigher, "sleeper", false))
+	t.Run("any_cabin", matchRequest(SearchCriteria{}, CabinEconomy, true))
+}
+
+func TestSearchCriteria_SearchCabins(t *testing.T) {
+	premium := SearchCriteria{CabinClass: CabinPremiumEconomy}
+	if diff := cmp.Diff([]string{CabinPremiumEconomy}, premium.SearchCabins()); diff != "" {
+		t.Fatalf("SearchCabins() mismatch (-want +got):\n%s", diff)
+	}
+
+	premium.IncludeHigherCabins = true
+	want := []string{CabinPremiumEconomy, CabinBusiness, CabinFirst}
+	if diff := cmp.Diff(want, premium.SearchCabins()); diff != "" {
+		t.Fatalf("SearchCabins() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSearchCriteria_FlexibleDates(t *testing.T) {
+	req := SearchCriteria{
+		DepartureDate: "2024-03-01",
+		FlexibleDays:  2,
+	}
+
+	want := []string{"2024-02-28", "2024-02-29", "2024-03-01", "2024-03-02", "2024-03-03"}
+	if diff := cmp.Diff(want, req.FlexibleDates()); diff != "" {
+		t.Fatalf("FlexibleDates() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSearchCriteria_InboundCriteria(t *testing.T) {
+	req := SearchCriteria{
+		Origin:        "CGK",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		Passengers:    2,
+		CabinClass:    "economy",
+	}
+
+	want := SearchCriteria{
+		Origin:        "DPS",
+		Destination:   "CGK",
+		DepartureDate: "2024-01-05",
+		Passengers:    2,
+		CabinClass:    "economy",
+	}
+
+	if diff := cmp.Diff(want, req.InboundCriteria()); diff != "" {
+		t.Fatalf("InboundCriteria() mismatch (-want +got):\n%s", diff)
+	}
 }
 
 func TestSearchCriteria_Bind(t *testing.T) {
@@ -117,3 +378,223 @@ func TestSearchCriteria_Bind(t *testing.T) {
 	t.Run("valid_bind", bindRequest(validCriteria, false))
 	t.Run("invalid_bind", bindRequest(SearchCriteria{}, true))
 }
+
+func TestSearchCriteria_BindAmenities(t *testing.T) {
+	_ = InitValidator()
+
+	bindRequest := func(amenities []string, wantStatus int) func(t *testing.T) {
+		return func(t *testing.T) {
+			req := SearchCriteria{
+				Origin:        "JKT",
+				Destination:   "DPS",
+				DepartureDate: "2024-01-01",
+				Passengers:    1,
+				CabinClass:    "economy",
+				FilterOption:  &FilterOption{Amenities: amenities},
+			}
+
+			err := req.Bind(httptest.NewRequest(http.MethodPost, "/api/v1/flights/search", nil))
+			if wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Bind() unexpected error: %v", err)
+				}
+				return
+			}
+
+			var appErr exception.ApplicationError
+			if !errors.As(err, &appErr) {
+				t.Fatalf("Bind() error %v is not an application error", err)
+			}
+
+			if diff := cmp.Diff(wantStatus, appErr.StatusCode); diff != "" {
+				t.Fatalf("Bind() status code mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("canonical_amenities", bindRequest([]string{AmenityWifi, AmenityPower}, 0))
+	t.Run("unknown_amenity", bindRequest([]string{AmenityWifi, "spa"}, http.StatusBadRequest))
+	// provider aliases are mapped before filtering, the filter only takes canonical names
+	t.Run("provider_alias", bindRequest([]string{"power_outlet"}, http.StatusBadRequest))
+}
+
+func TestSearchCriteria_BindLocale(t *testing.T) {
+	_ = InitValidator()
+
+	bindRequest := func(locale, acceptLanguage, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			req := SearchCriteria{
+				Origin:        "JKT",
+				Destination:   "DPS",
+				DepartureDate: "2024-01-01",
+				Passengers:    1,
+				CabinClass:    "economy",
+				Locale:        locale,
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/flights/search", nil)
+			r.Header.Set("Accept-Language", acceptLanguage)
+
+			if err := req.Bind(r); err != nil {
+				t.Fatalf("Bind() error = %v", err)
+			}
+
+			if diff := cmp.Diff(want, req.Locale); diff != "" {
+				t.Fatalf("Bind() locale mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("accept_language", bindRequest("", "ms-MY,en;q=0.8", "ms-MY"))
+	t.Run("locale_field_wins", bindRequest("en-SG", "id-ID", "en-SG"))
+	t.Run("unsupported_language", bindRequest("", "fr-FR", ""))
+}
+
+func TestSearchCriteria_BindQuery(t *testing.T) {
+	_ = InitValidator()
+
+	bindRequest := func(query string, want SearchCriteria, wantErr bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			var req SearchCriteria
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v1/flights/search/stream?"+query, nil)
+
+			err := req.Bind(r)
+			if (err != nil) != wantErr {
+				t.Fatalf("Bind() error = %v, wantErr %v", err, wantErr)
+			}
+
+			if wantErr {
+				return
+			}
+
+			if diff := cmp.Diff(want, req); diff != "" {
+				t.Fatalf("Bind() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	maxStops := 1
+
+	t.Run("query_string", bindRequest(
+		"origin=JKT&destination=DPS&departure_date=2024-01-01&adults=2&cabin_class=economy&locale=en-US"+
+			"&sort_field=price&sort_order=asc&filter_option=%7B%22max_stops%22%3A1%7D",
+		SearchCriteria{
+			Origin:         "JKT",
+			Destination:    "DPS",
+			DepartureDate:  "2024-01-01",
+			PassengerTypes: PassengerTypes{Adults: 2},
+			CabinClass:     "economy",
+			Locale:         "en-US",
+			SortOption:     &SortOption{Field: "price", Order: "asc"},
+			FilterOption:   &FilterOption{MaxStops: &maxStops},
+		},
+		false,
+	))
+	t.Run("include_facets", bindRequest(
+		"origin=JKT&destination=DPS&departure_date=2024-01-01&passengers=1&cabin_class=economy&include_facets=true",
+		SearchCriteria{
+			Origin:        "JKT",
+			Destination:   "DPS",
+			DepartureDate: "2024-01-01",
+			Passengers:    1,
+			CabinClass:    "economy",
+			IncludeFacets: true,
+		},
+		false,
+	))
+	t.Run("invalid_boolean", bindRequest(
+		"origin=JKT&destination=DPS&departure_date=2024-01-01&passengers=1&cabin_class=economy&include_facets=yes",
+		SearchCriteria{}, true))
+	t.Run("invalid_number", bindRequest(
+		"origin=JKT&destination=DPS&departure_date=2024-01-01&passengers=two&cabin_class=economy",
+		SearchCriteria{}, true))
+	t.Run("invalid_filter_option", bindRequest(
+		"origin=JKT&destination=DPS&departure_date=2024-01-01&passengers=1&cabin_class=economy&filter_option=max",
+		SearchCriteria{}, true))
+}
+
+func TestSearchFlightResponse_MarshalJSON(t *testing.T) {
+	marshalRequest := func(response SearchFlightResponse, wantFlights string, wantKey bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			data, err := json.Marshal(response)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			flights, ok := got["flights"]
+			if ok != wantKey {
+				t.Fatalf("flights key present = %v, want %v", ok, wantKey)
+			}
+
+			if wantKey && string(flights) != wantFlights {
+				t.Fatalf("flights = %s, want %s", flights, wantFlights)
+			}
+		}
+	}
+
+	t.Run("empty_one_way_search", marshalRequest(SearchFlightResponse{}, "[]", true))
+	t.Run("round_trip_search", marshalRequest(SearchFlightResponse{
+		SearchCriteria: SearchCriteria{DepartureDate: "2024-01-01", ReturnDate: "2024-01-05"},
+		Itineraries:    []Itinerary{{ID: "GA-1"}},
+	}, "", false))
+}
+
+func TestMultiCitySearchCriteria_Validate(t *testing.T) {
+	_ = InitValidator()
+
+	validateRequest := func(req MultiCitySearchCriteria, wantErr bool, wantMsg string) func(t *testing.T) {
+		return func(t *testing.T) {
+			err := req.Validate()
+			if (err != nil) != wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, wantErr)
+			}
+
+			if wantErr && err != nil {
+				if diff := cmp.Diff(wantMsg, err.Error()); diff != "" {
+					t.Fatalf("Validate() error message mismatch (-want +got):\n%s", diff)
+				}
+			}
+		}
+	}
+
+	t.Run("valid_open_jaw", validateRequest(MultiCitySearchCriteria{
+		Legs: []MultiCityLeg{
+			{Origin: "CGK", Destination: "DPS", DepartureDate: "2024-01-01"},
+			{Origin: "SUB", Destination: "CGK", DepartureDate: "2024-01-03"},
+		},
+		Passengers: 1,
+		CabinClass: "economy",
+	}, false, ""))
+
+	t.Run("single_leg", validateRequest(MultiCitySearchCriteria{
+		Legs: []MultiCityLeg{
+			{Origin: "CGK", Destination: "DPS", DepartureDate: "2024-01-01"},
+		},
+		Passengers: 1,
+		CabinClass: "economy",
+	}, true, "legs must contain at least 2 items"))
+
+	t.Run("missing_leg_origin", validateRequest(MultiCitySearchCriteria{
+		Legs: []MultiCityLeg{
+			{Origin: "CGK", Destination: "DPS", DepartureDate: "2024-01-01"},
+			{Destination: "SUB", DepartureDate: "2024-01-02"},
+		},
+		Passengers: 1,
+		CabinClass: "economy",
+	}, true, "origin is a required field"))
+
+	t.Run("legs_out_of_order", validateRequest(MultiCitySearchCriteria{
+		Legs: []MultiCityLeg{
+			{Origin: "CGK", Destination: "DPS", DepartureDate: "2024-01-03"},
+			{Origin: "DPS", Destination: "SUB", DepartureDate: "2024-01-01"},
+		},
+		Passengers: 1,
+		CabinClass: "economy",
+	}, true, "legs[1].departure_date must not be before the previous leg"))
+}
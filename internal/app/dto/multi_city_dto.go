@@ -0,0 +1,127 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
+)
+
+// MultiCityLeg is one one-way leg of a multi-city trip
+type MultiCityLeg struct {
+	Origin        string `json:"origin" validate:"required"`
+	Destination   string `json:"destination" validate:"required"`
+	DepartureDate string `json:"departure_date" validate:"required"`
+}
+
+// MultiCitySearchCriteria searches an ordered list of legs, e.g. CGK→DPS, DPS→SUB, SUB→CGK.
+// legs don't have to connect, so open-jaw trips are allowed
+type MultiCitySearchCriteria struct {
+	Legs       []MultiCityLeg `json:"legs" validate:"required,min=2,max=5,dive"`
+	Passengers int            `json:"passengers,omitempty" validate:"omitempty,min=1,max=10"`
+	PassengerTypes
+	CabinClass           string `json:"cabin_class" validate:"required,oneof=economy premium_economy business first"`
+	IncludeHigherCabins  bool   `json:"include_higher_cabins,omitempty"`
+	MinConnectionMinutes *int   `json:"min_connection_minutes,omitempty" validate:"omitempty,gte=0"`
+	NearbyAirportsKm     int    `json:"nearby_airports_km,omitempty" validate:"omitempty,min=0,max=300"`
+	DisplayCurrency      string `json:"display_currency,omitempty" validate:"omitempty,len=3,alpha"`
+	// Locale formats prices and durations, defaults to the Accept-Language header
+	Locale string `json:"locale,omitempty" validate:"omitempty,oneof=id-ID en-US en-SG ms-MY"`
+	// TimeoutMs overrides the search deadline of every leg
+	TimeoutMs    int           `json:"timeout_ms,omitempty" validate:"omitempty,min=100,max=60000"`
+	SortOption   *SortOption   `json:"sort_option,omitempty"`
+	FilterOption *FilterOption `json:"filter_option,omitempty"`
+}
+
+func (s *MultiCitySearchCriteria) Bind(r *http.Request) error {
+	if s.Locale == "" && r != nil {
+		s.Locale = locale.Negotiate(r.Header.Get("Accept-Language"))
+	}
+
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("error validate request: %w", err)
+	}
+
+	return nil
+}
+
+func (s *MultiCitySearchCriteria) Validate() error {
+	if err := ValidateSingleError(s); err != nil {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}
+	}
+
+	if err := validatePassengers(s.Passengers, s.PassengerTypes); err != nil {
+		return err
+	}
+
+	var previousDate time.Time
+	for i, leg := range s.Legs {
+		departureDate, err := time.Parse(DateFormat, leg.DepartureDate)
+		if err != nil {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("legs[%d].departure_date must be in YYYY-MM-DD format", i),
+			}
+		}
+
+		if departureDate.Before(previousDate) {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("legs[%d].departure_date must not be before the previous leg", i),
+			}
+		}
+
+		previousDate = departureDate
+	}
+
+	if s.SortOption != nil {
+		if err := s.SortOption.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if s.FilterOption != nil {
+		if err := s.FilterOption.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// TargetCurrency is the currency prices are converted to before they are compared
+func (s MultiCitySearchCriteria) TargetCurrency() string {
+	return targetCurrency(s.DisplayCurrency)
+}
+
+// PassengerCounts returns the passengers by type
+func (s MultiCitySearchCriteria) PassengerCounts() PassengerTypes {
+	return passengerTypes(s.Passengers, s.PassengerTypes)
+}
+
+// LegCriteria returns the one-way search of the leg at index i
+func (s MultiCitySearchCriteria) LegCriteria(i int) SearchCriteria {
+	return SearchCriteria{
+		Origin:              s.Legs[i].Origin,
+		Destination:         s.Legs[i].Destination,
+		DepartureDate:       s.Legs[i].DepartureDate,
+		Passengers:          s.Passengers,
+		PassengerTypes:      s.PassengerTypes,
+		CabinClass:          s.CabinClass,
+		IncludeHigherCabins: s.IncludeHigherCabins,
+		NearbyAirportsKm:    s.NearbyAirportsKm,
+		TimeoutMs:           s.TimeoutMs,
+	}
+}
+
+// MultiCitySearchResponse is the response struct for the multi-city search endpoint
+type MultiCitySearchResponse struct {
+	SearchCriteria MultiCitySearchCriteria `json:"search_criteria"`
+	Metadata       Metadata                `json:"metadata"`
+	Itineraries    []Itinerary             `json:"itineraries"`
+}
@@ -0,0 +1,106 @@
+package dto
+
+import (
+	"net/http"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+)
+
+// MaxPassengers is the maximum number of passengers of a search, infants included
+const MaxPassengers = 10
+
+// passenger types of the fares
+const (
+	PassengerTypeAdult  = "adult"
+	PassengerTypeChild  = "child"
+	PassengerTypeInfant = "infant"
+)
+
+// PassengerTypes counts the passengers by type, infants sit on an adult's lap
+// so they don't need a seat
+type PassengerTypes struct {
+	Adults   int `json:"adults,omitempty" validate:"omitempty,min=0,max=10"`
+	Children int `json:"children,omitempty" validate:"omitempty,min=0,max=10"`
+	Infants  int `json:"infants,omitempty" validate:"omitempty,min=0,max=10"`
+}
+
+// IsSet reports whether the passengers are counted by type
+func (p PassengerTypes) IsSet() bool {
+	return p.Adults+p.Children+p.Infants > 0
+}
+
+// SeatCount is the number of seats needed by the passengers
+func (p PassengerTypes) SeatCount() int {
+	return p.Adults + p.Children
+}
+
+// validatePassengers checks the passengers of a search, passengers is the legacy
+// total of adults and can't be combined with the passenger types
+func validatePassengers(passengers int, types PassengerTypes) error {
+	if passengers == 0 && !types.IsSet() {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "passengers or adults is a required field",
+		}
+	}
+
+	if passengers > 0 && types.IsSet() {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "passengers can't be combined with adults, children and infants",
+		}
+	}
+
+	if !types.IsSet() {
+		return nil
+	}
+
+	if types.Adults < 1 {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "adults must be 1 or more",
+		}
+	}
+
+	if types.Infants > types.Adults {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "infants must not be more than adults",
+		}
+	}
+
+	if types.Adults+types.Children+types.Infants > MaxPassengers {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "total passengers must be 10 or less",
+		}
+	}
+
+	return nil
+}
+
+// passengerTypes returns the passengers by type, the legacy passengers are all adults
+func passengerTypes(passengers int, types PassengerTypes) PassengerTypes {
+	if types.IsSet() {
+		return types
+	}
+
+	return PassengerTypes{Adults: passengers}
+}
+
+// PassengerFare is the fare of every passenger of one type
+type PassengerFare struct {
+	Type      string `json:"type"`
+	Count     int    `json:"count"`
+	UnitPrice Price  `json:"unit_price"`
+	Total     Price  `json:"total"`
+	// Derived fares are estimated from the adult fare, the provider didn't price the passenger type
+	Derived bool `json:"derived"`
+}
+
+// Fares prices every passenger of the search,
+// the price of the flight is the fare of one adult
+type Fares struct {
+	Passengers []PassengerFare `json:"passengers"`
+	Total      Price           `json:"total"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+)
+
+// status of an asynchronous search session
+const (
+	SearchStatusPending   = "pending"
+	SearchStatusCompleted = "completed"
+	SearchStatusFailed    = "failed"
+)
+
+// SearchSession is the state of a search running in the background. providers move from
+// pending to completed or failed as they answer and the flights so far are filtered, ranked
+// and sorted every time. once completed the flights, itineraries and calendar are the same
+// as the synchronous search and the metadata is set
+type SearchSession struct {
+	ID                 string         `json:"id"`
+	Status             string         `json:"status"`
+	SearchCriteria     SearchCriteria `json:"search_criteria"`
+	PendingProviders   []string       `json:"pending_providers"`
+	CompletedProviders []string       `json:"completed_providers"`
+	// FailedProviders failed or were skipped by their open circuit breaker
+	FailedProviders []string      `json:"failed_providers"`
+	Flights         []Flight      `json:"flights"`
+	Itineraries     []Itinerary   `json:"itineraries,omitempty"`
+	Calendar        []CalendarDay `json:"calendar,omitempty"`
+	Facets          *Facets       `json:"facets,omitempty"`
+	Metadata        *Metadata     `json:"metadata,omitempty"`
+	NextCursor      string        `json:"next_cursor,omitempty"`
+	Error           string        `json:"error,omitempty"`
+}
+
+// SearchSessionRequest reads the search ID from the path, e.g. /api/v1/flights/searches/{id}
+type SearchSessionRequest struct {
+	ID string `json:"id" validate:"required,uuid"`
+}
+
+func (s *SearchSessionRequest) Bind(r *http.Request) error {
+	s.ID = chi.URLParam(r, "id")
+
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("error validate request: %w", err)
+	}
+
+	return nil
+}
+
+func (s *SearchSessionRequest) Validate() error {
+	if err := ValidateSingleError(s); err != nil {
+		return exception.ApplicationError{
+			StatusCode: http.StatusBadRequest,
+			Message:    err.Error(),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package dto
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
+)
+
+// events of a streaming search
+const (
+	StreamEventProvider = "provider"
+	StreamEventResult   = "result"
+	StreamEventError    = "error"
+)
+
+// status of a provider in a streaming search
+const (
+	ProviderStatusSucceeded = "succeeded"
+	ProviderStatusFailed    = "failed"
+	ProviderStatusSkipped   = "skipped"
+)
+
+// SearchStreamEvent is one server-sent event of a streaming search, Data is
+// a ProviderEvent, the final SearchFlightResponse or an ErrorResponse
+type SearchStreamEvent struct {
+	Event string
+	Data  interface{}
+}
+
+// ProviderEvent is sent as soon as a provider answered the search, its flights are
+// converted, priced and filtered but only ranked and sorted in the final result
+type ProviderEvent struct {
+	Provider  string   `json:"provider"`
+	Direction string   `json:"direction,omitempty"`
+	Status    string   `json:"status"`
+	Flights   []Flight `json:"flights"`
+}
+
+// bindQuery reads the search criteria of a GET request from the query string, e.g.
+// ?origin=CGK&destination=DPS&departure_date=2025-12-15&cabin_class=economy&sort_field=price.
+// filter_option is the JSON encoded filter option
+func (s *SearchCriteria) bindQuery(query url.Values) error {
+	s.Origin = query.Get("origin")
+	s.Destination = query.Get("destination")
+	s.DepartureDate = query.Get("departure_date")
+	s.ReturnDate = query.Get("return_date")
+	s.CabinClass = query.Get("cabin_class")
+	s.DisplayCurrency = query.Get("display_currency")
+	s.Locale = query.Get("locale")
+	s.Cursor = query.Get("cursor")
+
+	ints := map[string]*int{
+		"flexible_days":      &s.FlexibleDays,
+		"nearby_airports_km": &s.NearbyAirportsKm,
+		"passengers":         &s.Passengers,
+		"adults":             &s.Adults,
+		"children":           &s.Children,
+		"infants":            &s.Infants,
+		"timeout_ms":         &s.TimeoutMs,
+		"page_size":          &s.PageSize,
+	}
+	for name, field := range ints {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("%s must be a number", name),
+			}
+		}
+		*field = parsed
+	}
+
+	bools := map[string]*bool{
+		"include_higher_cabins": &s.IncludeHigherCabins,
+		"include_facets":        &s.IncludeFacets,
+	}
+	for name, field := range bools {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    fmt.Sprintf("%s must be a boolean", name),
+			}
+		}
+		*field = parsed
+	}
+
+	if field := query.Get("sort_field"); field != "" {
+		s.SortOption = &SortOption{Field: field, Order: query.Get("sort_order")}
+	}
+
+	if filter := query.Get("filter_option"); filter != "" {
+		s.FilterOption = &FilterOption{}
+		if err := json.Unmarshal([]byte(filter), s.FilterOption); err != nil {
+			return exception.ApplicationError{
+				StatusCode: http.StatusBadRequest,
+				Message:    "filter_option must be a JSON object",
+			}
+		}
+	}
+
+	return nil
+}
@@ -1,5 +1,8 @@
 package dto
 
+// DateFormat is the layout of every date field in the search request
+const DateFormat = "2006-01-02"
+
 var AllowedSortField = map[string]bool{
 	"price":          true,
 	"duration":       true,
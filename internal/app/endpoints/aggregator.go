@@ -11,15 +11,27 @@ import (
 
 type AggregatorService interface {
 	SearchFlights(ctx context.Context, req dto.SearchCriteria) (dto.SearchFlightResponse, error)
+	SearchMultiCity(ctx context.Context, req dto.MultiCitySearchCriteria) (dto.MultiCitySearchResponse, error)
+	StreamSearchFlights(ctx context.Context, req dto.SearchCriteria) (<-chan dto.SearchStreamEvent, error)
+	CreateSearch(ctx context.Context, req dto.SearchCriteria) (dto.SearchSession, error)
+	GetSearch(ctx context.Context, req dto.SearchSessionRequest) (dto.SearchSession, error)
 }
 
 type AggregatorEndpoint struct {
-	SearchFlights endpoint.Endpoint
+	SearchFlights   endpoint.Endpoint
+	SearchMultiCity endpoint.Endpoint
+	StreamSearch    endpoint.Endpoint
+	CreateSearch    endpoint.Endpoint
+	GetSearch       endpoint.Endpoint
 }
 
 func MakeAggregatorEndpoint(service AggregatorService) AggregatorEndpoint {
 	return AggregatorEndpoint{
-		SearchFlights: makeSearchFlightsEndpoint(service),
+		SearchFlights:   makeSearchFlightsEndpoint(service),
+		SearchMultiCity: makeSearchMultiCityEndpoint(service),
+		StreamSearch:    makeStreamSearchEndpoint(service),
+		CreateSearch:    makeCreateSearchEndpoint(service),
+		GetSearch:       makeGetSearchEndpoint(service),
 	}
 }
 
@@ -38,3 +50,67 @@ func makeSearchFlightsEndpoint(service AggregatorService) endpoint.Endpoint {
 		return flight, nil
 	}
 }
+
+func makeSearchMultiCityEndpoint(service AggregatorService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request, ok := req.(*dto.MultiCitySearchCriteria)
+		if !ok || request == nil {
+			return nil, errors.New("invalid type")
+		}
+
+		itineraries, err := service.SearchMultiCity(ctx, *request)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator service: %w", err)
+		}
+
+		return itineraries, nil
+	}
+}
+
+func makeStreamSearchEndpoint(service AggregatorService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request, ok := req.(*dto.SearchCriteria)
+		if !ok || request == nil {
+			return nil, errors.New("invalid type")
+		}
+
+		events, err := service.StreamSearchFlights(ctx, *request)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator service: %w", err)
+		}
+
+		return events, nil
+	}
+}
+
+func makeCreateSearchEndpoint(service AggregatorService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request, ok := req.(*dto.SearchCriteria)
+		if !ok || request == nil {
+			return nil, errors.New("invalid type")
+		}
+
+		session, err := service.CreateSearch(ctx, *request)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator service: %w", err)
+		}
+
+		return session, nil
+	}
+}
+
+func makeGetSearchEndpoint(service AggregatorService) endpoint.Endpoint {
+	return func(ctx context.Context, req interface{}) (interface{}, error) {
+		request, ok := req.(*dto.SearchSessionRequest)
+		if !ok || request == nil {
+			return nil, errors.New("invalid type")
+		}
+
+		session, err := service.GetSearch(ctx, *request)
+		if err != nil {
+			return nil, fmt.Errorf("aggregator service: %w", err)
+		}
+
+		return session, nil
+	}
+}
@@ -2,4 +2,5 @@ package endpoints
 
 type Endpoints struct {
 	AggregatorEndpoint AggregatorEndpoint
+	AirportEndpoint    AirportEndpoint
 }
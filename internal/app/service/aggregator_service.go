@@ -5,12 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flight"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
 )
 
 type FlightCacher interface {
@@ -28,36 +35,95 @@ type FlightCacher interface {
 	) error
 }
 
+// SearchSessionStore stores the state of the searches running in the background
+type SearchSessionStore interface {
+	GetSession(ctx context.Context, id string) (dto.SearchSession, error)
+	SetSession(ctx context.Context, session dto.SearchSession, expiration time.Duration) error
+}
+
 type providerResult struct {
-	Provider string
-	Flights  []dto.Flight
-	Error    error
+	Provider  string
+	Direction string
+	Flights   []dto.Flight
+	Error     error
 }
 
+// resultHandler is called with every provider result as soon as it arrives
+type resultHandler func(providerResult)
+
+// DefaultMinConnectionTime is the minimum time between the arrival of a leg
+// and the departure of the next leg of a multi-city trip
+const DefaultMinConnectionTime = time.Hour
+
+// DefaultSessionExpiration is how long a background search keeps its session when it isn't configured
+const DefaultSessionExpiration = 10 * time.Minute
+
+// DefaultCursorExpiration is how long the next pages of a search can be read when it isn't configured
+const DefaultCursorExpiration = 30 * time.Minute
+
 type AggregatorService struct {
 	ProviderFactory       *flightprovider.FlightProviderFactory
 	Cache                 FlightCacher
 	FlightCacheExpiration time.Duration
 	FlightLockTimeout     time.Duration
+	Rates                 currency.RateProvider
+	// SearchDeadline is how long a search waits for the providers, 0 waits for all of them
+	SearchDeadline time.Duration
+	Sessions       SearchSessionStore
+	// SessionExpiration is how long a background search keeps its session after the last update
+	SessionExpiration time.Duration
+	// CursorExpiration is how long the flight set of a paginated search is kept for its next pages
+	CursorExpiration time.Duration
+	// FareRatios derive the fares of children and infants from the adult fare
+	FareRatios flight.FareRatios
 }
 
 func NewAggregatorService(providerFactory *flightprovider.FlightProviderFactory,
 	cache FlightCacher, flightCacheExpiration time.Duration,
-	flightLockTimeout time.Duration) *AggregatorService {
+	flightLockTimeout time.Duration, rates currency.RateProvider,
+	searchDeadline time.Duration, sessions SearchSessionStore,
+	sessionExpiration time.Duration, cursorExpiration time.Duration,
+	fareRatios flight.FareRatios) *AggregatorService {
+	if sessionExpiration <= 0 {
+		sessionExpiration = DefaultSessionExpiration
+	}
+
+	if cursorExpiration <= 0 {
+		cursorExpiration = DefaultCursorExpiration
+	}
+
+	if fareRatios.Child <= 0 {
+		fareRatios.Child = flight.DefaultFareRatios.Child
+	}
+
+	if fareRatios.Infant <= 0 {
+		fareRatios.Infant = flight.DefaultFareRatios.Infant
+	}
+
 	return &AggregatorService{
 		ProviderFactory:       providerFactory,
 		Cache:                 cache,
 		FlightCacheExpiration: flightCacheExpiration,
+		Rates:                 rates,
+		SearchDeadline:        searchDeadline,
+		Sessions:              sessions,
+		SessionExpiration:     sessionExpiration,
+		CursorExpiration:      cursorExpiration,
+		FareRatios:            fareRatios,
 	}
 }
 
 // SearchFlights aggregates flights from all providers and returns the best flights
 // It uses filter, rank, and sort functions to process the flights
+// when return date is set, both legs are searched and combined into itineraries
+// when flexible days is set, the calendar around the departure date is returned too
+// prices and durations are formatted for the requested locale last
 // SearchFlights godoc
 // @Summary      Search flights
 // @Tags         Flights
 // @Description  Search flights from all providers and return the best flights
-// @Param        request  body      dto.SearchCriteria  true  "Search Criteria"
+// @Param        request          body      dto.SearchCriteria  true   "Search Criteria"
+// @Param        Accept-Language  header    string              false  "Locale of the formatted prices and durations when locale is not set"
 // @Success      200      {object}  dto.SearchFlightResponse
 // @Failure      404      {object}  dto.ErrorResponse
 // @Failure      400      {object}  dto.ErrorResponse
@@ -66,15 +132,450 @@ func NewAggregatorService(providerFactory *flightprovider.FlightProviderFactory,
 func (s *AggregatorService) SearchFlights(
 	ctx context.Context,
 	req dto.SearchCriteria,
+) (dto.SearchFlightResponse, error) {
+	if err := s.validateCurrency(ctx, req.TargetCurrency()); err != nil {
+		return dto.SearchFlightResponse{}, err
+	}
+
+	response, err := s.searchFlights(ctx, req, nil)
+	if err != nil {
+		return dto.SearchFlightResponse{}, err
+	}
+
+	// cached flights stay locale-neutral, the response is formatted last
+	localizeResponse(response, req.Locale)
+
+	return response, nil
+}
+
+// StreamSearchFlights searches flights like SearchFlights but sends an event with the flights of every
+// provider as soon as it answers, then the ranked, sorted and filtered result or the error of the search.
+// a cache hit only sends the result. the channel is closed after the last event and the remaining
+// provider calls are cancelled with ctx when the client disconnects
+// StreamSearchFlights godoc
+// @Summary      Stream flight search
+// @Tags         Flights
+// @Description  Search flights from all providers and stream the flights of every provider as server-sent events,
+// @Description  followed by a result event with the best flights or an error event
+// @Param        request          body      dto.SearchCriteria  true   "Search Criteria, read from the query string on GET"
+// @Param        Accept-Language  header    string              false  "Locale of the formatted prices and durations when locale is not set"
+// @Produce      text/event-stream
+// @Success      200      {object}  dto.ProviderEvent
+// @Failure      400      {object}  dto.ErrorResponse
+// @Failure      500      {object}  dto.ErrorResponse
+// @Router       /api/v1/flights/search/stream [post]
+// @Router       /api/v1/flights/search/stream [get]
+func (s *AggregatorService) StreamSearchFlights(
+	ctx context.Context,
+	req dto.SearchCriteria,
+) (<-chan dto.SearchStreamEvent, error) {
+	// an unsupported currency fails the request before the stream starts
+	if err := s.validateCurrency(ctx, req.TargetCurrency()); err != nil {
+		return nil, err
+	}
+
+	l, localized := locale.Lookup(req.Locale)
+	events := make(chan dto.SearchStreamEvent, len(s.ProviderFactory.GetAllProviders())*2+1)
+
+	send := func(event dto.SearchStreamEvent) {
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+
+		response, err := s.searchFlights(ctx, req, func(result providerResult) {
+			event := dto.ProviderEvent{
+				Provider:  result.Provider,
+				Direction: result.Direction,
+				Status:    dto.ProviderStatusSucceeded,
+				Flights:   []dto.Flight{},
+			}
+
+			switch {
+			case errors.Is(result.Error, providerutils.ErrCircuitOpen):
+				event.Status = dto.ProviderStatusSkipped
+			case result.Error != nil:
+				event.Status = dto.ProviderStatusFailed
+			default:
+				convertedFlights := flight.ConvertPrices(ctx, result.Flights, s.Rates, req.TargetCurrency())
+				flight.ApplyFares(convertedFlights, req.PassengerCounts(), s.FareRatios)
+				event.Flights = flight.FilterFlights(ctx, convertedFlights, req.FilterOption)
+				if localized {
+					flight.LocalizeFlights(event.Flights, l)
+				}
+			}
+
+			send(dto.SearchStreamEvent{Event: dto.StreamEventProvider, Data: event})
+		})
+
+		// nobody is listening anymore
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			send(dto.SearchStreamEvent{Event: dto.StreamEventError, Data: clientError(ctx, err)})
+			return
+		}
+
+		localizeResponse(response, req.Locale)
+		send(dto.SearchStreamEvent{Event: dto.StreamEventResult, Data: response})
+	}()
+
+	return events, nil
+}
+
+// clientError is the error of a search that failed after its response started, the status code
+// is already sent so only the message of an application error is shown to the client
+func clientError(ctx context.Context, err error) dto.ErrorResponse {
+	var appErr exception.ApplicationError
+	if errors.As(err, &appErr) {
+		return dto.ErrorResponse{Error: appErr.Message}
+	}
+
+	slog.ErrorContext(ctx, "failed to search flights", slog.Any("error", err))
+
+	return dto.ErrorResponse{Error: "failed to search flights"}
+}
+
+// localizeResponse formats every price and duration of the response for the locale,
+// the response is left as is when the locale isn't supported
+func localizeResponse(response dto.SearchFlightResponse, code string) {
+	if l, ok := locale.Lookup(code); ok {
+		flight.LocalizeFlights(response.Flights, l)
+		flight.LocalizeItineraries(response.Itineraries, l)
+		flight.LocalizeCalendar(response.Calendar, l)
+		flight.LocalizeFacets(response.Facets, l)
+	}
+}
+
+// searchFlights calls onResult with the provider results of a cache miss,
+// flexible-date searches don't report the results of every date. the currency
+// is validated by the callers before the search starts
+func (s *AggregatorService) searchFlights(
+	ctx context.Context,
+	req dto.SearchCriteria,
+	onResult resultHandler,
+) (dto.SearchFlightResponse, error) {
+	startTime := time.Now()
+
+	if req.IsFlexible() {
+		return s.searchFlexibleDates(ctx, req, startTime)
+	}
+
+	// the next pages are served from the flight set of the first page, providers aren't searched again
+	if req.Cursor != "" {
+		return s.searchNextPage(ctx, req, startTime)
+	}
+
+	flights, metadata, cacheHit, err := s.getFlights(ctx, req, onResult)
+	if err != nil {
+		return dto.SearchFlightResponse{}, err
+	}
+
+	metadata.CacheHit = cacheHit
+
+	if req.IsRoundTrip() {
+		itineraries := s.processItineraries(ctx, splitByDirection(flights), req.TargetCurrency(),
+			req.PassengerCounts(), req.FilterOption, req.SortOption, 0)
+
+		metadata.TotalResults = len(itineraries)
+		metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
+
+		if len(itineraries) == 0 {
+			return dto.SearchFlightResponse{}, ErrNoFlightsFound
+		}
+
+		return dto.SearchFlightResponse{
+			Itineraries:    itineraries,
+			SearchCriteria: req,
+			Metadata:       metadata,
+		}, nil
+	}
+
+	sortedFlights, facets := s.processFlights(ctx, flights, req)
+
+	// metadata
+	metadata.TotalResults = len(sortedFlights)
+	metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
+
+	if len(sortedFlights) == 0 {
+		return dto.SearchFlightResponse{}, ErrNoFlightsFound
+	}
+
+	response := dto.SearchFlightResponse{
+		Flights:        sortedFlights,
+		Facets:         facets,
+		SearchCriteria: req,
+		Metadata:       metadata,
+	}
+
+	if req.IsPaginated() && len(sortedFlights) > req.PageSize {
+		// the flight set is kept apart from the cache entry so the next pages stay the same
+		// when the cache expires and is refreshed, searches of the same entry share the snapshot
+		key := flight.SnapshotKey(s.Cache.GetCacheKey(req), flights)
+		if err := s.Cache.SetFlight(ctx, key, flights, metadata, s.CursorExpiration); err != nil {
+			return dto.SearchFlightResponse{}, fmt.Errorf("failed to set page flights to cache: %w", err)
+		}
+
+		response.Flights = flight.Paginate(sortedFlights, 0, req.PageSize)
+		response.NextCursor = flight.EncodeCursor(flight.Cursor{
+			Key:         key,
+			Fingerprint: flight.SearchFingerprint(req),
+			Offset:      req.PageSize,
+		})
+	}
+
+	return response, nil
+}
+
+// searchNextPage returns the page of the cursor from the flight set stored with the first page,
+// the cursor must come from the same search with the same filter and sort
+func (s *AggregatorService) searchNextPage(
+	ctx context.Context,
+	req dto.SearchCriteria,
+	startTime time.Time,
+) (dto.SearchFlightResponse, error) {
+	cursor, err := flight.DecodeCursor(req.Cursor)
+	if err != nil {
+		return dto.SearchFlightResponse{}, ErrInvalidCursor
+	}
+
+	if !strings.HasPrefix(cursor.Key, s.Cache.GetCacheKey(req)+":page:") ||
+		cursor.Fingerprint != flight.SearchFingerprint(req) {
+		return dto.SearchFlightResponse{}, ErrInvalidCursor
+	}
+
+	flights, err := s.Cache.GetFlight(ctx, cursor.Key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get page flights from cache", slog.String("error", err.Error()))
+		return dto.SearchFlightResponse{}, ErrCursorExpired
+	}
+
+	metadata, err := s.Cache.GetMetadata(ctx, cursor.Key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get page metadata from cache", slog.String("error", err.Error()))
+	}
+
+	sortedFlights, facets := s.processFlights(ctx, flights, req)
+
+	metadata.CacheHit = true
+	metadata.TotalResults = len(sortedFlights)
+	metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
+
+	page := flight.Paginate(sortedFlights, cursor.Offset, req.PageSize)
+	if len(page) == 0 {
+		return dto.SearchFlightResponse{}, ErrNoFlightsFound
+	}
+
+	response := dto.SearchFlightResponse{
+		Flights:        page,
+		Facets:         facets,
+		SearchCriteria: req,
+		Metadata:       metadata,
+	}
+
+	if next := cursor.Offset + req.PageSize; next < len(sortedFlights) {
+		cursor.Offset = next
+		response.NextCursor = flight.EncodeCursor(cursor)
+	}
+
+	return response, nil
+}
+
+// searchFlexibleDates searches every date around the departure date concurrently,
+// every date is cached on its own so it is shared with exact-date searches
+func (s *AggregatorService) searchFlexibleDates(
+	ctx context.Context,
+	req dto.SearchCriteria,
+	startTime time.Time,
 ) (dto.SearchFlightResponse, error) {
 	var (
-		flights                 []dto.Flight
-		metadata                dto.Metadata
-		numberOfProviders       int
-		numberOfFailedProviders int
+		dates         = req.FlexibleDates()
+		requested     = req.FlexibleDays
+		dateFlights   = make([][]dto.Flight, len(dates))
+		dateMetadata  = make([]dto.Metadata, len(dates))
+		dateCacheHit  = make([]bool, len(dates))
+		dateErrors    = make([]error, len(dates))
+		calendar      = make([]dto.CalendarDay, len(dates))
+		filteredDates = make([][]dto.Flight, len(dates))
+		facets        *dto.Facets
+		wg            sync.WaitGroup
 	)
 
+	wg.Add(len(dates))
+	for i, date := range dates {
+		go func(i int, date string) {
+			defer wg.Done()
+			dateFlights[i], dateMetadata[i], dateCacheHit[i], dateErrors[i] = s.getFlights(ctx, req.DateCriteria(date), nil)
+		}(i, date)
+	}
+	wg.Wait()
+
+	// only the requested date must succeed, other dates are shown as empty days
+	if dateErrors[requested] != nil {
+		return dto.SearchFlightResponse{}, dateErrors[requested]
+	}
+
+	totalCalendarResults := 0
+	for i, date := range dates {
+		if dateErrors[i] != nil {
+			slog.WarnContext(ctx, "failed to search flexible date",
+				slog.String("date", date),
+				slog.String("error", dateErrors[i].Error()))
+		}
+
+		convertedFlights := flight.ConvertPrices(ctx, dateFlights[i], s.Rates, req.TargetCurrency())
+		mergedFlights := flight.MergeDuplicates(convertedFlights)
+		flight.ApplyFares(mergedFlights, req.PassengerCounts(), s.FareRatios)
+		// the facets only count the flights of the requested date
+		if i == requested && req.IncludeFacets {
+			facets = flight.BuildFacets(ctx, mergedFlights, req.FilterOption)
+		}
+		filteredDates[i] = flight.FilterFlights(ctx, mergedFlights, req.FilterOption)
+		calendar[i] = flight.BuildCalendarDay(date, filteredDates[i])
+		totalCalendarResults += calendar[i].TotalResults
+	}
+
+	rankedFlights := flight.RankFlights(filteredDates[requested])
+	sortedFlights := flight.SortFlights(rankedFlights, req.SortOption)
+
+	metadata := dateMetadata[requested]
+	metadata.CacheHit = dateCacheHit[requested]
+	metadata.TotalResults = len(sortedFlights)
+	metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
+
+	if totalCalendarResults == 0 {
+		return dto.SearchFlightResponse{}, ErrNoFlightsFound
+	}
+
+	return dto.SearchFlightResponse{
+		Flights:        sortedFlights,
+		Calendar:       calendar,
+		Facets:         facets,
+		SearchCriteria: req,
+		Metadata:       metadata,
+	}, nil
+}
+
+// SearchMultiCity searches every leg of a multi-city trip and returns the best itineraries
+// every leg is searched concurrently and shares the cache with one-way searches
+// SearchMultiCity godoc
+// @Summary      Search multi-city flights
+// @Tags         Flights
+// @Description  Search every leg of a multi-city trip from all providers and return the best itineraries
+// @Param        request          body      dto.MultiCitySearchCriteria  true   "Multi-City Search Criteria"
+// @Param        Accept-Language  header    string                       false  "Locale of the formatted prices and durations when locale is not set"
+// @Success      200      {object}  dto.MultiCitySearchResponse
+// @Failure      404      {object}  dto.ErrorResponse
+// @Failure      400      {object}  dto.ErrorResponse
+// @Failure      500      {object}  dto.ErrorResponse
+// @Router       /api/v1/flights/search/multi-city [post]
+func (s *AggregatorService) SearchMultiCity(
+	ctx context.Context,
+	req dto.MultiCitySearchCriteria,
+) (dto.MultiCitySearchResponse, error) {
 	startTime := time.Now()
+
+	if err := s.validateCurrency(ctx, req.TargetCurrency()); err != nil {
+		return dto.MultiCitySearchResponse{}, err
+	}
+
+	var (
+		legs        = make([][]dto.Flight, len(req.Legs))
+		legMetadata = make([]dto.Metadata, len(req.Legs))
+		legCacheHit = make([]bool, len(req.Legs))
+		legErrors   = make([]error, len(req.Legs))
+		wg          sync.WaitGroup
+	)
+
+	wg.Add(len(req.Legs))
+	for i := range req.Legs {
+		go func(i int) {
+			defer wg.Done()
+			legs[i], legMetadata[i], legCacheHit[i], legErrors[i] = s.getFlights(ctx, req.LegCriteria(i), nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, err := range legErrors {
+		if err != nil {
+			return dto.MultiCitySearchResponse{}, fmt.Errorf("failed to search leg %d: %w", i, err)
+		}
+	}
+
+	minConnection := DefaultMinConnectionTime
+	if req.MinConnectionMinutes != nil {
+		minConnection = time.Duration(*req.MinConnectionMinutes) * time.Minute
+	}
+
+	itineraries := s.processItineraries(ctx, legs, req.TargetCurrency(), req.PassengerCounts(), req.FilterOption, req.SortOption, minConnection)
+
+	metadata := mergeLegMetadata(legMetadata, legCacheHit)
+	metadata.TotalResults = len(itineraries)
+	metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
+
+	if len(itineraries) == 0 {
+		return dto.MultiCitySearchResponse{}, ErrNoFlightsFound
+	}
+
+	if l, ok := locale.Lookup(req.Locale); ok {
+		flight.LocalizeItineraries(itineraries, l)
+	}
+
+	return dto.MultiCitySearchResponse{
+		Itineraries:    itineraries,
+		SearchCriteria: req,
+		Metadata:       metadata,
+	}, nil
+}
+
+// mergeLegMetadata reports the provider counts of the worst leg,
+// the search is only a cache hit when every leg hit the cache
+func mergeLegMetadata(legMetadata []dto.Metadata, legCacheHit []bool) dto.Metadata {
+	metadata := dto.Metadata{CacheHit: true}
+	for i, leg := range legMetadata {
+		metadata.ProvidersQueried = max(metadata.ProvidersQueried, leg.ProvidersQueried)
+		metadata.ProvidersFailed = max(metadata.ProvidersFailed, leg.ProvidersFailed)
+		metadata.ProvidersSkipped = max(metadata.ProvidersSkipped, leg.ProvidersSkipped)
+		metadata.ProvidersTimedOut = max(metadata.ProvidersTimedOut, leg.ProvidersTimedOut)
+		metadata.TimedOutProviders = mergeProviders(metadata.TimedOutProviders, leg.TimedOutProviders)
+		metadata.CacheHit = metadata.CacheHit && legCacheHit[i]
+	}
+	metadata.ProvidersSucceeded = max(0, metadata.ProvidersQueried-metadata.ProvidersFailed-
+		metadata.ProvidersSkipped-metadata.ProvidersTimedOut)
+
+	return metadata
+}
+
+// mergeProviders returns the sorted union of the provider names
+func mergeProviders(providers, others []string) []string {
+	for _, other := range others {
+		if !slices.Contains(providers, other) {
+			providers = append(providers, other)
+		}
+	}
+	sort.Strings(providers)
+
+	return providers
+}
+
+// getFlights returns the unfiltered flights of the search from cache,
+// or from the providers when the cache missed
+func (s *AggregatorService) getFlights(
+	ctx context.Context,
+	req dto.SearchCriteria,
+	onResult resultHandler,
+) ([]dto.Flight, dto.Metadata, bool, error) {
+	var (
+		flights  []dto.Flight
+		metadata dto.Metadata
+	)
+
 	cacheHit := false
 
 	// get from cache first
@@ -93,111 +594,250 @@ func (s *AggregatorService) SearchFlights(
 		slog.WarnContext(ctx, "failed to get metadata from cache", slog.String("error", err.Error()))
 	}
 
+	if cacheHit {
+		return flights, metadata, cacheHit, nil
+	}
+
 	// cache miss get from provider and store to cache
-	if !cacheHit {
-		// if there is concurrent request with same criteria, only one will be processed
-		// to save to cache so next request with same criterial will hit the cache
-		// e.g. 3 request
-		// request 1 -> acquire lock, process, save to cache, release lock
-		// request 2 -> acquire lock, lock not aquired, get from provider
-		// request 3 -> acquire lock, lock not aquired, get from provider
-		// this ensure only 1 operation that fetch from provider and save to cache
-
-		// get fligt and metadata
-		flights, numberOfProviders,
-			numberOfFailedProviders, err = s.getFromProvider(ctx, req)
-		if err != nil && !errors.Is(err, ErrNoFlightsFound) {
-			return dto.SearchFlightResponse{}, fmt.Errorf("failed to get flights from providers: %w", err)
-		}
-
-		metadata = dto.Metadata{
-			ProvidersQueried:   numberOfProviders,
-			ProvidersSucceeded: numberOfProviders - numberOfFailedProviders,
-			ProvidersFailed:    numberOfFailedProviders,
-		}
-
-		// lock to process cache
-		acquired, err := s.Cache.AcquireLock(ctx, lockKey, s.FlightLockTimeout)
+	// if there is concurrent request with same criteria, only one will be processed
+	// to save to cache so next request with same criterial will hit the cache
+	// e.g. 3 request
+	// request 1 -> acquire lock, process, save to cache, release lock
+	// request 2 -> acquire lock, lock not aquired, get from provider
+	// request 3 -> acquire lock, lock not aquired, get from provider
+	// this ensure only 1 operation that fetch from provider and save to cache
+
+	// get fligt and metadata
+	flights, metadata, err = s.getFromProvider(ctx, req, onResult)
+	if err != nil && !errors.Is(err, ErrNoFlightsFound) {
+		return nil, dto.Metadata{}, false, fmt.Errorf("failed to get flights from providers: %w", err)
+	}
+
+	// lock to process cache
+	acquired, err := s.Cache.AcquireLock(ctx, lockKey, s.FlightLockTimeout)
+	if err != nil {
+		return nil, dto.Metadata{}, false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer s.Cache.ReleaseLock(ctx, lockKey)
+
+	// partial results of timed out providers aren't cached
+	// so the next search asks the slow providers again
+	if acquired && metadata.ProvidersTimedOut == 0 {
+		// if lock is acquired, process the request and save to cache
+		err = s.Cache.SetFlight(ctx, cacheKey, flights, metadata, s.FlightCacheExpiration)
 		if err != nil {
-			return dto.SearchFlightResponse{}, fmt.Errorf("failed to acquire lock: %w", err)
+			return nil, dto.Metadata{}, false, fmt.Errorf("failed to set flights to cache: %w", err)
 		}
-		defer s.Cache.ReleaseLock(ctx, lockKey)
+	}
 
-		if acquired {
-			// if lock is acquired, process the request and save to cache
-			err = s.Cache.SetFlight(ctx, cacheKey, flights, metadata, s.FlightCacheExpiration)
-			if err != nil {
-				return dto.SearchFlightResponse{}, fmt.Errorf("failed to set flights to cache: %w", err)
-			}
+	return flights, metadata, cacheHit, nil
+}
 
-		}
+// processFlights converts, merges, prices, filters, ranks and sorts one-way flights,
+// the facets of the priced flights are returned too when they are requested
+func (s *AggregatorService) processFlights(ctx context.Context,
+	flights []dto.Flight,
+	req dto.SearchCriteria,
+) ([]dto.Flight, *dto.Facets) {
+	convertedFlights := flight.ConvertPrices(ctx, flights, s.Rates, req.TargetCurrency())
+	mergedFlights := flight.MergeDuplicates(convertedFlights)
+	flight.ApplyFares(mergedFlights, req.PassengerCounts(), s.FareRatios)
+
+	var facets *dto.Facets
+	if req.IncludeFacets {
+		facets = flight.BuildFacets(ctx, mergedFlights, req.FilterOption)
 	}
 
-	// filter, rank, and sort flights
-	filteredFlights := flight.FilterFlights(ctx, flights, req.FilterOption)
+	filteredFlights := flight.FilterFlights(ctx, mergedFlights, req.FilterOption)
 	rankedFlights := flight.RankFlights(filteredFlights)
-	sortedFlights := flight.SortFlights(rankedFlights, req.SortOption)
 
-	// metadata
-	metadata.TotalResults = len(sortedFlights)
-	metadata.SearchTimeMs = int(time.Since(startTime).Milliseconds())
-	metadata.CacheHit = cacheHit
+	return flight.SortFlights(rankedFlights, req.SortOption), facets
+}
 
-	if len(sortedFlights) == 0 {
-		return dto.SearchFlightResponse{}, ErrNoFlightsFound
+// processItineraries converts, merges, prices and filters every leg and combines them into ranked and sorted itineraries
+func (s *AggregatorService) processItineraries(ctx context.Context,
+	legs [][]dto.Flight,
+	displayCurrency string,
+	passengers dto.PassengerTypes,
+	filterOpts *dto.FilterOption,
+	sortOpts *dto.SortOption,
+	minConnection time.Duration,
+) []dto.Itinerary {
+	candidates := make([][]dto.Flight, len(legs))
+	for i, leg := range legs {
+		// best flights of every leg are combined first in case the itineraries are capped
+		convertedFlights := flight.ConvertPrices(ctx, leg, s.Rates, displayCurrency)
+		mergedFlights := flight.MergeDuplicates(convertedFlights)
+		flight.ApplyFares(mergedFlights, passengers, s.FareRatios)
+		filteredFlights := flight.FilterFlights(ctx, mergedFlights, filterOpts)
+		candidates[i] = flight.SortFlights(flight.RankFlights(filteredFlights), nil)
 	}
 
-	return dto.SearchFlightResponse{
-		Flights:        sortedFlights,
-		SearchCriteria: req,
-		Metadata:       metadata,
-	}, nil
+	itineraries := flight.BuildItineraries(candidates, minConnection)
+	rankedItineraries := flight.RankItineraries(itineraries)
+
+	return flight.SortItineraries(rankedItineraries, sortOpts)
+}
+
+// splitByDirection splits round-trip flights into outbound and inbound legs
+func splitByDirection(flights []dto.Flight) [][]dto.Flight {
+	var outbound, inbound []dto.Flight
+	for _, f := range flights {
+		switch f.Direction {
+		case dto.DirectionOutbound:
+			outbound = append(outbound, f)
+		case dto.DirectionInbound:
+			inbound = append(inbound, f)
+		}
+	}
+
+	return [][]dto.Flight{outbound, inbound}
 }
 
+// getFromProvider searches every provider until they all answered or the search deadline passed,
+// the metadata counts the providers that succeeded, failed, were skipped by their open circuit
+// breaker or timed out at the deadline. the flights collected by the deadline are returned
+// and onResult, when set, is called with every result received before the deadline
 func (s *AggregatorService) getFromProvider(ctx context.Context,
 	req dto.SearchCriteria,
-) ([]dto.Flight, int, int, error) {
+	onResult resultHandler,
+) ([]dto.Flight, dto.Metadata, error) {
 	providers := s.ProviderFactory.GetAllProviders()
-	results := make(chan providerResult, len(providers))
-	var wg sync.WaitGroup
+	legs := searchLegs(req)
+	results := make(chan providerResult, len(providers)*len(legs))
 
-	// concurrently call all providers
+	searchCtx, cancel := context.WithCancel(ctx)
+	if deadline := req.Deadline(s.SearchDeadline); deadline > 0 {
+		searchCtx, cancel = context.WithTimeout(ctx, deadline)
+	}
+	defer cancel()
+
+	// concurrently call all providers for every leg
 	// timeout for each provider is set in the provider itself
-	wg.Add(len(providers))
+	pending := make(map[string]int, len(providers))
 	for key, provider := range providers {
-		go func(key string, p flightprovider.FlightProvider) {
-			defer wg.Done()
-			flights, err := p.Search(ctx, req)
-			results <- providerResult{
-				Provider: key,
-				Flights:  flights,
-				Error:    err,
-			}
-		}(key, provider)
+		pending[key] = len(legs)
+		for _, leg := range legs {
+			go func(key string, p flightprovider.FlightProvider, leg searchLeg) {
+				flights, err := p.Search(searchCtx, leg.Criteria)
+				results <- providerResult{
+					Provider:  key,
+					Direction: leg.Direction,
+					Flights:   flights,
+					Error:     err,
+				}
+			}(key, provider, leg)
+		}
 	}
 
-	// wait all go routine finish
-	go func() {
-		wg.Wait()
-		close(results)
-	}()
-
-	numberOfFailedProviders := 0
+	// a provider is failed when any of its legs failed
+	// and skipped when any of its legs was skipped by the circuit breaker
+	failedProviders := map[string]bool{}
+	skippedProviders := map[string]bool{}
 	var allFlights []dto.Flight
-	for result := range results {
+collect:
+	for received := 0; received < len(providers)*len(legs); received++ {
+		var result providerResult
+		select {
+		case result = <-results:
+		case <-searchCtx.Done():
+			break collect
+		}
+
+		// a leg cut off by the deadline is still pending so the provider times out
+		if result.Error != nil && searchCtx.Err() != nil {
+			continue
+		}
+		pending[result.Provider]--
+
+		for i := range result.Flights {
+			result.Flights[i].Direction = result.Direction
+		}
+
+		if onResult != nil {
+			onResult(result)
+		}
+
+		if errors.Is(result.Error, providerutils.ErrCircuitOpen) {
+			slog.WarnContext(ctx, "provider skipped",
+				slog.String("provider", result.Provider),
+				slog.String("direction", result.Direction))
+			skippedProviders[result.Provider] = true
+			continue
+		}
+
 		if result.Error != nil {
 			slog.WarnContext(ctx, "provider failed",
 				slog.String("provider", result.Provider),
+				slog.String("direction", result.Direction),
 				slog.Any("error", result.Error))
-			numberOfFailedProviders++
+			failedProviders[result.Provider] = true
 			continue
 		}
+
 		allFlights = append(allFlights, result.Flights...)
 	}
 
+	var timedOutProviders []string
+	for provider, legs := range pending {
+		if legs > 0 && !failedProviders[provider] {
+			slog.WarnContext(ctx, "provider timed out", slog.String("provider", provider))
+			timedOutProviders = append(timedOutProviders, provider)
+			delete(skippedProviders, provider)
+		}
+	}
+	sort.Strings(timedOutProviders)
+
+	for provider := range failedProviders {
+		delete(skippedProviders, provider)
+	}
+
+	metadata := dto.Metadata{
+		ProvidersQueried: len(providers),
+		ProvidersSucceeded: len(providers) - len(failedProviders) - len(skippedProviders) -
+			len(timedOutProviders),
+		ProvidersFailed:   len(failedProviders),
+		ProvidersSkipped:  len(skippedProviders),
+		ProvidersTimedOut: len(timedOutProviders),
+		TimedOutProviders: timedOutProviders,
+	}
+
 	if len(allFlights) == 0 {
-		return []dto.Flight{}, len(providers), numberOfFailedProviders, ErrNoFlightsFound
+		return []dto.Flight{}, metadata, ErrNoFlightsFound
+	}
+
+	return allFlights, metadata, nil
+}
+
+// searchLeg is a one-way search sent to the providers
+type searchLeg struct {
+	Direction string
+	Criteria  dto.SearchCriteria
+}
+
+// searchLegs splits the search into the one-way searches sent to the providers.
+// one-way search flights are not tagged with a direction
+func searchLegs(req dto.SearchCriteria) []searchLeg {
+	if !req.IsRoundTrip() {
+		return []searchLeg{{Criteria: req}}
+	}
+
+	return []searchLeg{
+		{Direction: dto.DirectionOutbound, Criteria: req.OutboundCriteria()},
+		{Direction: dto.DirectionInbound, Criteria: req.InboundCriteria()},
+	}
+}
+
+// validateCurrency checks there is a rate to convert prices to the display currency
+// before any provider is queried
+func (s *AggregatorService) validateCurrency(ctx context.Context, code string) error {
+	if code == currency.DefaultCurrency {
+		return nil
+	}
+
+	if _, err := s.Rates.Rate(ctx, currency.DefaultCurrency, code); err != nil {
+		return ErrUnsupportedCurrency
 	}
 
-	return allFlights, len(providers), numberOfFailedProviders, nil
+	return nil
 }
@@ -5,12 +5,18 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flight"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -42,6 +48,7 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 				Cache:                 m.cache,
 				FlightCacheExpiration: 10 * time.Minute,
 				FlightLockTimeout:     5 * time.Second,
+				Rates:                 testRates(t),
 			}
 
 			got, err := s.SearchFlights(context.Background(), criteria)
@@ -85,6 +92,22 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 		},
 	}
 
+	// fares of the single adult of the criteria
+	adultFares := func(amount float64, formatted string) *dto.Fares {
+		price := dto.Price{Amount: amount, Currency: "IDR", Formatted: formatted}
+		return &dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{Type: dto.PassengerTypeAdult, Count: 1, UnitPrice: price, Total: price},
+			},
+			Total: price,
+		}
+	}
+
+	// a single flight only scores on the inverted amenities weight
+	rankedFlight := flights[0]
+	rankedFlight.Score = 0.05
+	rankedFlight.Fares = adultFares(1000000, "Rp1.000.000")
+
 	t.Run("cache_hit", searchFlightRequest(
 		criteria,
 		func(m mockField) {
@@ -97,7 +120,7 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 			}, nil)
 		},
 		dto.SearchFlightResponse{
-			Flights:        flights,
+			Flights:        []dto.Flight{rankedFlight},
 			SearchCriteria: criteria,
 			Metadata: dto.Metadata{
 				ProvidersQueried:   1,
@@ -122,7 +145,7 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 			m.cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
 		},
 		dto.SearchFlightResponse{
-			Flights:        flights,
+			Flights:        []dto.Flight{rankedFlight},
 			SearchCriteria: criteria,
 			Metadata: dto.Metadata{
 				ProvidersQueried:   1,
@@ -134,6 +157,92 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 		nil,
 	))
 
+	sgdCriteria := criteria
+	sgdCriteria.DisplayCurrency = "SGD"
+
+	sgdPrice := dto.Price{Amount: 100, Currency: "SGD", Formatted: "S$100.00"}
+	sgdFlight := flights[0]
+	sgdFlight.Price = sgdPrice
+	sgdFlight.Score = 0.05
+	sgdFlight.Fares = &dto.Fares{
+		Passengers: []dto.PassengerFare{
+			{Type: dto.PassengerTypeAdult, Count: 1, UnitPrice: sgdPrice, Total: sgdPrice},
+		},
+		Total: sgdPrice,
+	}
+
+	t.Run("display_currency", searchFlightRequest(
+		sgdCriteria,
+		func(m mockField) {
+			// the cache keeps the provider currency
+			m.cache.On("GetCacheKey", sgdCriteria).Return("cache-key")
+			m.cache.On("GetLockKey", sgdCriteria).Return("lock-key")
+			m.cache.On("GetFlight", mock.Anything, "cache-key").Return(flights, nil)
+			m.cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+			}, nil)
+		},
+		dto.SearchFlightResponse{
+			Flights:        []dto.Flight{sgdFlight},
+			SearchCriteria: sgdCriteria,
+			Metadata: dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+				TotalResults:       1,
+				CacheHit:           true,
+			},
+		},
+		nil,
+	))
+
+	localeCriteria := criteria
+	localeCriteria.Locale = "en-US"
+
+	localizedPrice := dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1,000,000"}
+	localizedFlight := rankedFlight
+	localizedFlight.Price = localizedPrice
+	localizedFlight.Duration = dto.Duration{Formatted: "0h"}
+	localizedFlight.Fares = &dto.Fares{
+		Passengers: []dto.PassengerFare{
+			{Type: dto.PassengerTypeAdult, Count: 1, UnitPrice: localizedPrice, Total: localizedPrice},
+		},
+		Total: localizedPrice,
+	}
+
+	t.Run("locale_formats_response", searchFlightRequest(
+		localeCriteria,
+		func(m mockField) {
+			m.cache.On("GetCacheKey", localeCriteria).Return("cache-key")
+			m.cache.On("GetLockKey", localeCriteria).Return("lock-key")
+			m.cache.On("GetFlight", mock.Anything, "cache-key").Return(flights, nil)
+			m.cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+			}, nil)
+		},
+		dto.SearchFlightResponse{
+			Flights:        []dto.Flight{localizedFlight},
+			SearchCriteria: localeCriteria,
+			Metadata: dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+				TotalResults:       1,
+				CacheHit:           true,
+			},
+		},
+		nil,
+	))
+
+	unsupportedCriteria := criteria
+	unsupportedCriteria.DisplayCurrency = "XYZ"
+	t.Run("unsupported_display_currency", searchFlightRequest(
+		unsupportedCriteria,
+		func(m mockField) {},
+		dto.SearchFlightResponse{},
+		ErrUnsupportedCurrency,
+	))
+
 	t.Run("no_flights_found", searchFlightRequest(
 		criteria,
 		func(m mockField) {
@@ -149,4 +258,582 @@ func TestAggregatorService_SearchFlights(t *testing.T) {
 		dto.SearchFlightResponse{},
 		ErrNoFlightsFound,
 	))
+
+	roundTripCriteria := criteria
+	roundTripCriteria.ReturnDate = "2024-01-05"
+
+	outboundFlights := []dto.Flight{
+		{
+			ID:        "outbound-1",
+			Provider:  "test-provider",
+			Departure: dto.Departure{Timestamp: 1000},
+			Arrival:   dto.Arrival{Timestamp: 2000},
+			Price:     dto.Price{Amount: 1000000, Currency: "IDR"},
+		},
+	}
+	inboundFlights := []dto.Flight{
+		{
+			ID:        "inbound-1",
+			Provider:  "test-provider",
+			Departure: dto.Departure{Timestamp: 3000},
+			Arrival:   dto.Arrival{Timestamp: 4000},
+			Price:     dto.Price{Amount: 500000, Currency: "IDR"},
+		},
+	}
+
+	// every leg is ranked before combining
+	taggedOutbound := outboundFlights[0]
+	taggedOutbound.Direction = dto.DirectionOutbound
+	taggedOutbound.Score = 0.05
+	taggedOutbound.Fares = adultFares(1000000, "Rp1.000.000")
+	taggedInbound := inboundFlights[0]
+	taggedInbound.Direction = dto.DirectionInbound
+	taggedInbound.Score = 0.05
+	taggedInbound.Fares = adultFares(500000, "Rp500.000")
+
+	t.Run("round_trip_cache_miss_success", searchFlightRequest(
+		roundTripCriteria,
+		func(m mockField) {
+			m.cache.On("GetCacheKey", roundTripCriteria).Return("cache-key")
+			m.cache.On("GetLockKey", roundTripCriteria).Return("lock-key")
+			m.cache.On("GetFlight", mock.Anything, "cache-key").Return(nil, errors.New("miss"))
+			m.cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{}, errors.New("miss"))
+			m.provider.On("Search", mock.Anything, roundTripCriteria.OutboundCriteria()).Return(outboundFlights, nil)
+			m.provider.On("Search", mock.Anything, roundTripCriteria.InboundCriteria()).Return(inboundFlights, nil)
+			m.cache.On("AcquireLock", mock.Anything, "lock-key", 5*time.Second).Return(true, nil)
+			m.cache.On("SetFlight", mock.Anything, "cache-key", mock.Anything, mock.Anything, 10*time.Minute).Return(nil)
+			m.cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
+		},
+		dto.SearchFlightResponse{
+			Itineraries: []dto.Itinerary{
+				{
+					ID:       "outbound-1+inbound-1",
+					Legs:     []dto.Flight{taggedOutbound, taggedInbound},
+					Price:    dto.Price{Amount: 1500000, Currency: "IDR", Formatted: "Rp1.500.000"},
+					Fares:    adultFares(1500000, "Rp1.500.000"),
+					Duration: dto.Duration{Formatted: "0h"},
+					Score:    0.05,
+				},
+			},
+			SearchCriteria: roundTripCriteria,
+			Metadata: dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+				TotalResults:       1,
+				CacheHit:           false,
+			},
+		},
+		nil,
+	))
+
+	flexibleCriteria := criteria
+	flexibleCriteria.FlexibleDays = 1
+
+	cheapFlight := dto.Flight{
+		ID:       "cheap-flight",
+		Provider: "test-provider",
+		Price:    dto.Price{Amount: 500000, Currency: "IDR"},
+		Duration: dto.Duration{TotalMinutes: 120},
+	}
+	t.Run("flexible_dates_calendar", searchFlightRequest(
+		flexibleCriteria,
+		func(m mockField) {
+			// the day before is cached, the day after has no flights
+			before := flexibleCriteria.DateCriteria("2023-12-31")
+			m.cache.On("GetCacheKey", before).Return("cache-key-before")
+			m.cache.On("GetLockKey", before).Return("lock-key-before")
+			m.cache.On("GetFlight", mock.Anything, "cache-key-before").Return([]dto.Flight{cheapFlight}, nil)
+			m.cache.On("GetMetadata", mock.Anything, "cache-key-before").Return(dto.Metadata{}, nil)
+
+			m.cache.On("GetCacheKey", criteria).Return("cache-key")
+			m.cache.On("GetLockKey", criteria).Return("lock-key")
+			m.cache.On("GetFlight", mock.Anything, "cache-key").Return(flights, nil)
+			m.cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+			}, nil)
+
+			after := flexibleCriteria.DateCriteria("2024-01-02")
+			m.cache.On("GetCacheKey", after).Return("cache-key-after")
+			m.cache.On("GetLockKey", after).Return("lock-key-after")
+			m.cache.On("GetFlight", mock.Anything, "cache-key-after").Return(nil, errors.New("miss"))
+			m.cache.On("GetMetadata", mock.Anything, "cache-key-after").Return(dto.Metadata{}, errors.New("miss"))
+			m.provider.On("Search", mock.Anything, after).Return(nil, ErrNoFlightsFound)
+			m.cache.On("AcquireLock", mock.Anything, "lock-key-after", 5*time.Second).Return(true, nil)
+			m.cache.On("SetFlight", mock.Anything, "cache-key-after", []dto.Flight{}, mock.Anything, 10*time.Minute).Return(nil)
+			m.cache.On("ReleaseLock", mock.Anything, "lock-key-after").Return(nil)
+		},
+		dto.SearchFlightResponse{
+			Flights: []dto.Flight{rankedFlight},
+			Calendar: []dto.CalendarDay{
+				{
+					Date:            "2023-12-31",
+					LowestPrice:     &cheapFlight.Price,
+					FastestDuration: &cheapFlight.Duration,
+					TotalResults:    1,
+				},
+				{
+					Date:            "2024-01-01",
+					LowestPrice:     &flights[0].Price,
+					FastestDuration: &flights[0].Duration,
+					TotalResults:    1,
+				},
+				{Date: "2024-01-02"},
+			},
+			SearchCriteria: flexibleCriteria,
+			Metadata: dto.Metadata{
+				ProvidersQueried:   1,
+				ProvidersSucceeded: 1,
+				TotalResults:       1,
+				CacheHit:           true,
+			},
+		},
+		nil,
+	))
+}
+
+func TestAggregatorService_getFromProvider(t *testing.T) {
+	getFromProviderRequest := func(errs map[string]error, wantMetadata dto.Metadata, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			factory := flightprovider.NewFlightProviderFactory()
+			for name, err := range errs {
+				provider := flightprovider.NewMockFlightProvider(t)
+				var flights []dto.Flight
+				if err == nil {
+					flights = []dto.Flight{{ID: name + "-1", Provider: name}}
+				}
+				provider.On("Search", mock.Anything, mock.Anything).Return(flights, err)
+				factory.AddProvider(name, provider)
+			}
+
+			s := &AggregatorService{ProviderFactory: factory}
+
+			_, got, err := s.getFromProvider(context.Background(), dto.SearchCriteria{}, nil)
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("expected error %v, got %v", wantErr, err)
+			}
+
+			diff := cmp.Diff(wantMetadata, got)
+			if diff != "" {
+				t.Fatalf("getFromProvider() metadata mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	circuitOpen := fmt.Errorf("open-provider: %w", providerutils.ErrCircuitOpen)
+
+	t.Run("open_circuit_is_skipped_not_failed", getFromProviderRequest(
+		map[string]error{
+			"ok-provider":     nil,
+			"failed-provider": providerutils.ErrProviderInternalError,
+			"open-provider":   circuitOpen,
+		},
+		dto.Metadata{
+			ProvidersQueried:   3,
+			ProvidersSucceeded: 1,
+			ProvidersFailed:    1,
+			ProvidersSkipped:   1,
+		},
+		nil,
+	))
+
+	t.Run("every_circuit_open", getFromProviderRequest(
+		map[string]error{"open-provider": circuitOpen},
+		dto.Metadata{ProvidersQueried: 1, ProvidersSkipped: 1},
+		ErrNoFlightsFound,
+	))
+
+	t.Run("deadline_returns_partial_results", func(t *testing.T) {
+		fast := flightprovider.NewMockFlightProvider(t)
+		fast.On("Search", mock.Anything, mock.Anything).Return([]dto.Flight{{ID: "fast-1"}}, nil)
+		slow := flightprovider.NewMockFlightProvider(t)
+		slow.On("Search", mock.Anything, mock.Anything).Return([]dto.Flight{{ID: "slow-1"}}, nil).
+			After(500 * time.Millisecond)
+
+		factory := flightprovider.NewFlightProviderFactory()
+		factory.AddProvider("fast-provider", fast)
+		factory.AddProvider("slow-provider", slow)
+
+		s := &AggregatorService{ProviderFactory: factory, SearchDeadline: time.Minute}
+
+		// the request deadline overrides the service deadline
+		flights, metadata, err := s.getFromProvider(context.Background(), dto.SearchCriteria{TimeoutMs: 100}, nil)
+		assert.NoError(t, err)
+
+		diff := cmp.Diff([]dto.Flight{{ID: "fast-1"}}, flights)
+		if diff != "" {
+			t.Fatalf("getFromProvider() flights mismatch (-want +got):\n%s", diff)
+		}
+
+		diff = cmp.Diff(dto.Metadata{
+			ProvidersQueried:   2,
+			ProvidersSucceeded: 1,
+			ProvidersTimedOut:  1,
+			TimedOutProviders:  []string{"slow-provider"},
+		}, metadata)
+		if diff != "" {
+			t.Fatalf("getFromProvider() metadata mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestAggregatorService_SearchMultiCity(t *testing.T) {
+	type mockField struct {
+		cache    *MockFlightCacher
+		provider *flightprovider.MockFlightProvider
+	}
+
+	searchMultiCityRequest := func(
+		criteria dto.MultiCitySearchCriteria,
+		setupMock func(m mockField),
+		wantIDs []string,
+		wantErr error,
+	) func(t *testing.T) {
+		return func(t *testing.T) {
+			m := mockField{
+				cache:    NewMockFlightCacher(t),
+				provider: flightprovider.NewMockFlightProvider(t),
+			}
+			setupMock(m)
+
+			factory := flightprovider.NewFlightProviderFactory()
+			factory.AddProvider("test-provider", m.provider)
+
+			s := &AggregatorService{
+				ProviderFactory:       factory,
+				Cache:                 m.cache,
+				FlightCacheExpiration: 10 * time.Minute,
+				FlightLockTimeout:     5 * time.Second,
+				Rates:                 testRates(t),
+			}
+
+			got, err := s.SearchMultiCity(context.Background(), criteria)
+
+			if wantErr != nil {
+				assert.ErrorIs(t, err, wantErr)
+				return
+			}
+
+			assert.NoError(t, err)
+			gotIDs := make([]string, len(got.Itineraries))
+			for i, itinerary := range got.Itineraries {
+				gotIDs[i] = itinerary.ID
+			}
+
+			diff := cmp.Diff(wantIDs, gotIDs)
+			if diff != "" {
+				t.Fatalf("SearchMultiCity() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	criteria := dto.MultiCitySearchCriteria{
+		Legs: []dto.MultiCityLeg{
+			{Origin: "CGK", Destination: "DPS", DepartureDate: "2024-01-01"},
+			{Origin: "DPS", Destination: "SUB", DepartureDate: "2024-01-01"},
+		},
+		Passengers: 1,
+		CabinClass: "economy",
+	}
+
+	firstLeg := []dto.Flight{
+		{ID: "CGK-DPS", Departure: dto.Departure{Timestamp: 0}, Arrival: dto.Arrival{Timestamp: 3600}},
+	}
+	secondLeg := []dto.Flight{
+		// departs 30 minutes after the first leg arrives
+		{ID: "DPS-SUB-early", Departure: dto.Departure{Timestamp: 5400}, Arrival: dto.Arrival{Timestamp: 9000}},
+		{ID: "DPS-SUB-late", Departure: dto.Departure{Timestamp: 14400}, Arrival: dto.Arrival{Timestamp: 18000}},
+	}
+
+	setupLegs := func(m mockField) {
+		m.cache.On("GetCacheKey", criteria.LegCriteria(0)).Return("leg-0")
+		m.cache.On("GetLockKey", criteria.LegCriteria(0)).Return("lock-0")
+		m.cache.On("GetFlight", mock.Anything, "leg-0").Return(firstLeg, nil)
+		m.cache.On("GetMetadata", mock.Anything, "leg-0").Return(dto.Metadata{ProvidersQueried: 1, ProvidersSucceeded: 1}, nil)
+		m.cache.On("GetCacheKey", criteria.LegCriteria(1)).Return("leg-1")
+		m.cache.On("GetLockKey", criteria.LegCriteria(1)).Return("lock-1")
+		m.cache.On("GetFlight", mock.Anything, "leg-1").Return(nil, errors.New("miss"))
+		m.cache.On("GetMetadata", mock.Anything, "leg-1").Return(dto.Metadata{}, errors.New("miss"))
+		m.provider.On("Search", mock.Anything, criteria.LegCriteria(1)).Return(secondLeg, nil)
+		m.cache.On("AcquireLock", mock.Anything, "lock-1", 5*time.Second).Return(true, nil)
+		m.cache.On("SetFlight", mock.Anything, "leg-1", secondLeg, mock.Anything, 10*time.Minute).Return(nil)
+		m.cache.On("ReleaseLock", mock.Anything, "lock-1").Return(nil)
+	}
+
+	t.Run("default_min_connection", searchMultiCityRequest(criteria, setupLegs,
+		[]string{"CGK-DPS+DPS-SUB-late"}, nil))
+
+	shortConnection := criteria
+	shortConnection.MinConnectionMinutes = func() *int { i := 30; return &i }()
+	t.Run("custom_min_connection", searchMultiCityRequest(shortConnection, setupLegs,
+		[]string{"CGK-DPS+DPS-SUB-early", "CGK-DPS+DPS-SUB-late"}, nil))
+
+	noConnection := criteria
+	noConnection.MinConnectionMinutes = func() *int { i := 600; return &i }()
+	t.Run("no_connecting_flights", searchMultiCityRequest(noConnection, setupLegs, nil, ErrNoFlightsFound))
+}
+
+func TestAggregatorService_SearchFlightsPagination(t *testing.T) {
+	criteria := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		PageSize:      2,
+		SortOption:    &dto.SortOption{Field: "price", Order: "asc"},
+	}
+
+	flights := []dto.Flight{
+		{ID: "flight-3", FlightNumber: "3", Price: dto.Price{Amount: 3000000, Currency: "IDR"}},
+		{ID: "flight-1", FlightNumber: "1", Price: dto.Price{Amount: 1000000, Currency: "IDR"}},
+		{ID: "flight-2", FlightNumber: "2", Price: dto.Price{Amount: 2000000, Currency: "IDR"}},
+	}
+
+	flightIDs := func(flights []dto.Flight) []string {
+		ids := make([]string, len(flights))
+		for i, f := range flights {
+			ids[i] = f.ID
+		}
+		return ids
+	}
+
+	cache := NewMockFlightCacher(t)
+	cache.On("GetCacheKey", mock.Anything).Return("flight:cache:key")
+	cache.On("GetLockKey", mock.Anything).Return("flight:lock:key")
+	cache.On("GetFlight", mock.Anything, "flight:cache:key").Return(flights, nil).Twice()
+	cache.On("GetMetadata", mock.Anything, "flight:cache:key").Return(dto.Metadata{ProvidersQueried: 1}, nil).Twice()
+
+	// the flight set of the first page is stored apart from the cache entry,
+	// keyed on the entry so the first pages of the same entry share it
+	snapshotKey := flight.SnapshotKey("flight:cache:key", flights)
+	cache.On("SetFlight", mock.Anything, snapshotKey, flights, mock.Anything, 30*time.Minute).
+		Return(nil).Twice()
+
+	s := &AggregatorService{
+		ProviderFactory:  flightprovider.NewFlightProviderFactory(),
+		Cache:            cache,
+		Rates:            testRates(t),
+		CursorExpiration: 30 * time.Minute,
+	}
+
+	first, err := s.SearchFlights(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"flight-1", "flight-2"}, flightIDs(first.Flights))
+	assert.Equal(t, 3, first.Metadata.TotalResults)
+	assert.NotEmpty(t, first.NextCursor)
+
+	again, err := s.SearchFlights(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Equal(t, first.NextCursor, again.NextCursor)
+
+	// the next page is read from the snapshot, not from the cache entry or the providers
+	cache.On("GetFlight", mock.Anything, snapshotKey).Return(flights, nil)
+	cache.On("GetMetadata", mock.Anything, snapshotKey).Return(dto.Metadata{ProvidersQueried: 1}, nil)
+
+	nextPage := criteria
+	nextPage.Cursor = first.NextCursor
+
+	second, err := s.SearchFlights(context.Background(), nextPage)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"flight-3"}, flightIDs(second.Flights))
+	assert.Equal(t, 3, second.Metadata.TotalResults)
+	assert.True(t, second.Metadata.CacheHit)
+	assert.Empty(t, second.NextCursor)
+
+	// a cursor can't continue a search with another sort
+	otherSort := nextPage
+	otherSort.SortOption = &dto.SortOption{Field: "price", Order: "desc"}
+
+	_, err = s.SearchFlights(context.Background(), otherSort)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+
+	invalid := criteria
+	invalid.Cursor = "not a cursor"
+
+	_, err = s.SearchFlights(context.Background(), invalid)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestAggregatorService_SearchFlightsFacets(t *testing.T) {
+	airline := "GA"
+	criteria := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+		Locale:        "en-US",
+		IncludeFacets: true,
+		FilterOption:  &dto.FilterOption{Airline: &airline},
+	}
+
+	flights := []dto.Flight{
+		{
+			ID: "GA-1", FlightNumber: "GA1", Airline: dto.Airline{Code: "GA"},
+			Price: dto.Price{Amount: 1500000, Currency: "IDR"}, Duration: dto.Duration{TotalMinutes: 120},
+		},
+		{
+			ID: "JT-1", FlightNumber: "JT1", Airline: dto.Airline{Code: "JT"},
+			Price: dto.Price{Amount: 1000000, Currency: "IDR"}, Duration: dto.Duration{TotalMinutes: 90},
+		},
+	}
+
+	cache := NewMockFlightCacher(t)
+	cache.On("GetCacheKey", mock.Anything).Return("flight:cache:key")
+	cache.On("GetLockKey", mock.Anything).Return("flight:lock:key")
+	cache.On("GetFlight", mock.Anything, "flight:cache:key").Return(flights, nil)
+	cache.On("GetMetadata", mock.Anything, "flight:cache:key").Return(dto.Metadata{ProvidersQueried: 1}, nil)
+
+	s := &AggregatorService{
+		ProviderFactory: flightprovider.NewFlightProviderFactory(),
+		Cache:           cache,
+		Rates:           testRates(t),
+	}
+
+	response, err := s.SearchFlights(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Len(t, response.Flights, 1)
+	assert.Equal(t, 1, response.Metadata.TotalResults)
+
+	// the airline facet ignores the airline filter
+	assert.NotNil(t, response.Facets)
+	assert.Len(t, response.Facets.Airlines, 2)
+	for _, facet := range response.Facets.Airlines {
+		assert.Equal(t, 1, facet.Count)
+		assert.NotEmpty(t, facet.MinPrice.Formatted)
+	}
+	// the other facets still apply it
+	assert.Equal(t, 120, response.Facets.Duration.Min.TotalMinutes)
+	assert.NotEmpty(t, response.Facets.Duration.Min.Formatted)
+
+	// facets are only built when requested
+	criteria.IncludeFacets = false
+	response, err = s.SearchFlights(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Nil(t, response.Facets)
+}
+
+func TestAggregatorService_StreamSearchFlights(t *testing.T) {
+	criteria := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}
+
+	streamRequest := func(setupMock func(cache *MockFlightCacher, factory *flightprovider.FlightProviderFactory),
+		wantEvents []string, wantStatuses []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			cache := NewMockFlightCacher(t)
+			factory := flightprovider.NewFlightProviderFactory()
+			setupMock(cache, factory)
+
+			s := &AggregatorService{
+				ProviderFactory:       factory,
+				Cache:                 cache,
+				FlightCacheExpiration: 10 * time.Minute,
+				FlightLockTimeout:     5 * time.Second,
+				Rates:                 testRates(t),
+			}
+
+			events, err := s.StreamSearchFlights(context.Background(), criteria)
+			assert.NoError(t, err)
+
+			var gotEvents, gotStatuses []string
+			for event := range events {
+				gotEvents = append(gotEvents, event.Event)
+				if provider, ok := event.Data.(dto.ProviderEvent); ok {
+					gotStatuses = append(gotStatuses, provider.Provider+":"+provider.Status)
+				}
+			}
+			slices.Sort(gotStatuses)
+
+			diff := cmp.Diff(wantEvents, gotEvents)
+			if diff != "" {
+				t.Fatalf("StreamSearchFlights() events mismatch (-want +got):\n%s", diff)
+			}
+
+			diff = cmp.Diff(wantStatuses, gotStatuses)
+			if diff != "" {
+				t.Fatalf("StreamSearchFlights() statuses mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	cacheMiss := func(cache *MockFlightCacher) {
+		cache.On("GetCacheKey", criteria).Return("cache-key")
+		cache.On("GetLockKey", criteria).Return("lock-key")
+		cache.On("GetFlight", mock.Anything, "cache-key").Return(nil, errors.New("miss"))
+		cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{}, errors.New("miss"))
+	}
+
+	t.Run("provider_events_then_result", streamRequest(
+		func(cache *MockFlightCacher, factory *flightprovider.FlightProviderFactory) {
+			cacheMiss(cache)
+			cache.On("AcquireLock", mock.Anything, "lock-key", 5*time.Second).Return(true, nil)
+			cache.On("SetFlight", mock.Anything, "cache-key", mock.Anything, mock.Anything, 10*time.Minute).Return(nil)
+			cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
+
+			ok := flightprovider.NewMockFlightProvider(t)
+			ok.On("Search", mock.Anything, criteria).Return([]dto.Flight{
+				{ID: "ok-1", Provider: "ok-provider", Price: dto.Price{Amount: 1000000, Currency: "IDR"}},
+			}, nil)
+			failed := flightprovider.NewMockFlightProvider(t)
+			failed.On("Search", mock.Anything, criteria).Return(nil, providerutils.ErrProviderInternalError)
+
+			factory.AddProvider("ok-provider", ok)
+			factory.AddProvider("failed-provider", failed)
+		},
+		[]string{dto.StreamEventProvider, dto.StreamEventProvider, dto.StreamEventResult},
+		[]string{"failed-provider:failed", "ok-provider:succeeded"},
+	))
+
+	t.Run("no_flights_is_error_event", streamRequest(
+		func(cache *MockFlightCacher, factory *flightprovider.FlightProviderFactory) {
+			cacheMiss(cache)
+			cache.On("AcquireLock", mock.Anything, "lock-key", 5*time.Second).Return(false, nil)
+			cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
+
+			open := flightprovider.NewMockFlightProvider(t)
+			open.On("Search", mock.Anything, criteria).Return(nil, providerutils.ErrCircuitOpen)
+			factory.AddProvider("open-provider", open)
+		},
+		[]string{dto.StreamEventProvider, dto.StreamEventError},
+		[]string{"open-provider:skipped"},
+	))
+
+	t.Run("cache_hit_only_sends_result", streamRequest(
+		func(cache *MockFlightCacher, _ *flightprovider.FlightProviderFactory) {
+			cache.On("GetCacheKey", criteria).Return("cache-key")
+			cache.On("GetLockKey", criteria).Return("lock-key")
+			cache.On("GetFlight", mock.Anything, "cache-key").Return([]dto.Flight{
+				{ID: "cached-1", Price: dto.Price{Amount: 1000000, Currency: "IDR"}},
+			}, nil)
+			cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{}, nil)
+		},
+		[]string{dto.StreamEventResult},
+		nil,
+	))
+
+	t.Run("unsupported_currency_fails_before_stream", func(t *testing.T) {
+		s := &AggregatorService{ProviderFactory: flightprovider.NewFlightProviderFactory(), Rates: testRates(t)}
+
+		unsupported := criteria
+		unsupported.DisplayCurrency = "XYZ"
+
+		_, err := s.StreamSearchFlights(context.Background(), unsupported)
+		assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	})
+}
+
+// testRates converts 1 IDR to 0.0001 SGD
+func testRates(t *testing.T) currency.RateProvider {
+	rates, err := currency.Load(strings.NewReader(`{"base": "IDR", "rates": {"SGD": 0.0001}}`))
+	if err != nil {
+		t.Fatalf("failed to load test rates: %v", err)
+	}
+
+	return rates
 }
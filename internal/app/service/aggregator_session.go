@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flight"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
+)
+
+// CreateSearch starts the search in the background and returns its pending session right away,
+// the session is updated every time a provider answers and expires after the session expiration
+// CreateSearch godoc
+// @Summary      Create asynchronous flight search
+// @Tags         Flights
+// @Description  Start a flight search in the background and return its ID to poll the results
+// @Param        request          body      dto.SearchCriteria  true   "Search Criteria"
+// @Param        Accept-Language  header    string              false  "Locale of the formatted prices and durations when locale is not set"
+// @Success      202      {object}  dto.SearchSession
+// @Failure      400      {object}  dto.ErrorResponse
+// @Failure      500      {object}  dto.ErrorResponse
+// @Router       /api/v1/flights/searches [post]
+func (s *AggregatorService) CreateSearch(
+	ctx context.Context,
+	req dto.SearchCriteria,
+) (dto.SearchSession, error) {
+	if err := s.validateCurrency(ctx, req.TargetCurrency()); err != nil {
+		return dto.SearchSession{}, err
+	}
+
+	providers := make([]string, 0, len(s.ProviderFactory.GetAllProviders()))
+	for name := range s.ProviderFactory.GetAllProviders() {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	session := dto.SearchSession{
+		ID:                 uuid.New().String(),
+		Status:             dto.SearchStatusPending,
+		SearchCriteria:     req,
+		PendingProviders:   providers,
+		CompletedProviders: []string{},
+		FailedProviders:    []string{},
+		Flights:            []dto.Flight{},
+	}
+
+	if err := s.Sessions.SetSession(ctx, session, s.SessionExpiration); err != nil {
+		return dto.SearchSession{}, fmt.Errorf("failed to create search session: %w", err)
+	}
+
+	// the search outlives the request but not its session
+	searchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.SessionExpiration)
+	go func() {
+		defer cancel()
+		s.runSearch(searchCtx, session)
+	}()
+
+	return session, nil
+}
+
+// GetSearch returns the current state of a background search
+// GetSearch godoc
+// @Summary      Get asynchronous flight search
+// @Tags         Flights
+// @Description  Return the pending and completed providers and the flights so far of a background search
+// @Param        id   path      string  true  "Search ID"
+// @Success      200      {object}  dto.SearchSession
+// @Failure      400      {object}  dto.ErrorResponse
+// @Failure      404      {object}  dto.ErrorResponse
+// @Failure      500      {object}  dto.ErrorResponse
+// @Router       /api/v1/flights/searches/{id} [get]
+func (s *AggregatorService) GetSearch(ctx context.Context, req dto.SearchSessionRequest) (dto.SearchSession, error) {
+	session, err := s.Sessions.GetSession(ctx, req.ID)
+	if errors.Is(err, flight.ErrSessionNotFound) {
+		return dto.SearchSession{}, ErrSearchNotFound
+	}
+	if err != nil {
+		return dto.SearchSession{}, fmt.Errorf("failed to get search session: %w", err)
+	}
+
+	return session, nil
+}
+
+// runSearch searches the session and stores the flights so far every time a provider answered,
+// a provider of a round-trip search answered once both legs arrived
+func (s *AggregatorService) runSearch(ctx context.Context, session dto.SearchSession) {
+	var (
+		req        = session.SearchCriteria
+		l, ok      = locale.Lookup(req.Locale)
+		remaining  = map[string]int{}
+		allFlights []dto.Flight
+	)
+
+	// the pending providers are shared with the session returned to the client
+	session.PendingProviders = slices.Clone(session.PendingProviders)
+	for _, provider := range session.PendingProviders {
+		remaining[provider] = len(searchLegs(req))
+	}
+
+	response, err := s.searchFlights(ctx, req, func(result providerResult) {
+		remaining[result.Provider]--
+
+		if result.Error != nil && !slices.Contains(session.FailedProviders, result.Provider) {
+			session.FailedProviders = append(session.FailedProviders, result.Provider)
+		}
+		allFlights = append(allFlights, result.Flights...)
+
+		if remaining[result.Provider] > 0 {
+			return
+		}
+
+		session.PendingProviders = slices.DeleteFunc(session.PendingProviders, func(provider string) bool {
+			return provider == result.Provider
+		})
+		if !slices.Contains(session.FailedProviders, result.Provider) {
+			session.CompletedProviders = append(session.CompletedProviders, result.Provider)
+		}
+
+		// round trips are combined into itineraries like the final response, never listed as flights
+		if req.IsRoundTrip() {
+			session.Flights = []dto.Flight{}
+			session.Itineraries = s.processItineraries(ctx, splitByDirection(allFlights), req.TargetCurrency(),
+				req.PassengerCounts(), req.FilterOption, req.SortOption, 0)
+			if ok {
+				flight.LocalizeItineraries(session.Itineraries, l)
+			}
+		} else {
+			session.Flights, session.Facets = s.processFlights(ctx, allFlights, req)
+			if ok {
+				flight.LocalizeFlights(session.Flights, l)
+				flight.LocalizeFacets(session.Facets, l)
+			}
+		}
+
+		s.saveSession(ctx, session)
+	})
+
+	// providers of a cached or flexible-date search don't report their results, the other providers
+	// still pending timed out at the deadline and are listed in the metadata
+	if err == nil {
+		for _, provider := range session.PendingProviders {
+			if !slices.Contains(response.Metadata.TimedOutProviders, provider) {
+				session.CompletedProviders = append(session.CompletedProviders, provider)
+			}
+		}
+	}
+	session.PendingProviders = []string{}
+	sort.Strings(session.CompletedProviders)
+	sort.Strings(session.FailedProviders)
+
+	if err != nil {
+		session.Status = dto.SearchStatusFailed
+		session.Error = clientError(ctx, err).Error
+		s.saveSession(ctx, session)
+
+		return
+	}
+
+	localizeResponse(response, req.Locale)
+
+	session.Status = dto.SearchStatusCompleted
+	session.Flights = response.Flights
+	if session.Flights == nil {
+		session.Flights = []dto.Flight{}
+	}
+	session.Itineraries = response.Itineraries
+	session.Calendar = response.Calendar
+	session.Facets = response.Facets
+	session.Metadata = &response.Metadata
+	session.NextCursor = response.NextCursor
+	s.saveSession(ctx, session)
+}
+
+// saveSession stores the session, a failed update is retried by the next one
+func (s *AggregatorService) saveSession(ctx context.Context, session dto.SearchSession) {
+	if err := s.Sessions.SetSession(ctx, session, s.SessionExpiration); err != nil {
+		slog.WarnContext(ctx, "failed to save search session",
+			slog.String("id", session.ID),
+			slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,210 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flight"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAggregatorService_CreateSearch(t *testing.T) {
+	criteria := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}
+
+	cache := NewMockFlightCacher(t)
+	cache.On("GetCacheKey", criteria).Return("cache-key")
+	cache.On("GetLockKey", criteria).Return("lock-key")
+	cache.On("GetFlight", mock.Anything, "cache-key").Return(nil, errors.New("miss"))
+	cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{}, errors.New("miss"))
+	cache.On("AcquireLock", mock.Anything, "lock-key", 5*time.Second).Return(true, nil)
+	cache.On("SetFlight", mock.Anything, "cache-key", mock.Anything, mock.Anything, 10*time.Minute).Return(nil)
+	cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
+
+	ok := flightprovider.NewMockFlightProvider(t)
+	ok.On("Search", mock.Anything, criteria).Return([]dto.Flight{
+		{ID: "ok-1", Provider: "ok-provider", Price: dto.Price{Amount: 1000000, Currency: "IDR"}},
+	}, nil)
+	failed := flightprovider.NewMockFlightProvider(t)
+	failed.On("Search", mock.Anything, criteria).Return(nil, providerutils.ErrProviderInternalError)
+
+	factory := flightprovider.NewFlightProviderFactory()
+	factory.AddProvider("ok-provider", ok)
+	factory.AddProvider("failed-provider", failed)
+
+	saved := make(chan dto.SearchSession, 10)
+	sessions := NewMockSearchSessionStore(t)
+	sessions.EXPECT().SetSession(mock.Anything, mock.Anything, time.Minute).
+		Run(func(_ context.Context, session dto.SearchSession, _ time.Duration) {
+			saved <- session
+		}).Return(nil)
+
+	s := &AggregatorService{
+		ProviderFactory:       factory,
+		Cache:                 cache,
+		FlightCacheExpiration: 10 * time.Minute,
+		FlightLockTimeout:     5 * time.Second,
+		Rates:                 testRates(t),
+		Sessions:              sessions,
+		SessionExpiration:     time.Minute,
+	}
+
+	session, err := s.CreateSearch(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Equal(t, dto.SearchStatusPending, session.Status)
+	assert.Equal(t, []string{"failed-provider", "ok-provider"}, session.PendingProviders)
+
+	// created, one update per provider, then completed
+	var got dto.SearchSession
+	for i := 0; i < 4; i++ {
+		select {
+		case got = <-saved:
+		case <-time.After(time.Second):
+			t.Fatalf("search session %d was not saved", i)
+		}
+		assert.Equal(t, session.ID, got.ID)
+	}
+
+	assert.Equal(t, dto.SearchStatusCompleted, got.Status)
+
+	diff := cmp.Diff([]string{}, got.PendingProviders)
+	if diff != "" {
+		t.Fatalf("CreateSearch() pending providers mismatch (-want +got):\n%s", diff)
+	}
+
+	diff = cmp.Diff([]string{"ok-provider"}, got.CompletedProviders)
+	if diff != "" {
+		t.Fatalf("CreateSearch() completed providers mismatch (-want +got):\n%s", diff)
+	}
+
+	diff = cmp.Diff([]string{"failed-provider"}, got.FailedProviders)
+	if diff != "" {
+		t.Fatalf("CreateSearch() failed providers mismatch (-want +got):\n%s", diff)
+	}
+
+	assert.Len(t, got.Flights, 1)
+	assert.Equal(t, 1, got.Metadata.ProvidersFailed)
+}
+
+func TestAggregatorService_CreateSearch_RoundTrip(t *testing.T) {
+	criteria := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		ReturnDate:    "2024-01-05",
+		Passengers:    1,
+		CabinClass:    "economy",
+	}
+
+	cache := NewMockFlightCacher(t)
+	cache.On("GetCacheKey", criteria).Return("cache-key")
+	cache.On("GetLockKey", criteria).Return("lock-key")
+	cache.On("GetFlight", mock.Anything, "cache-key").Return(nil, errors.New("miss"))
+	cache.On("GetMetadata", mock.Anything, "cache-key").Return(dto.Metadata{}, errors.New("miss"))
+	cache.On("AcquireLock", mock.Anything, "lock-key", 5*time.Second).Return(true, nil)
+	cache.On("SetFlight", mock.Anything, "cache-key", mock.Anything, mock.Anything, 10*time.Minute).Return(nil)
+	cache.On("ReleaseLock", mock.Anything, "lock-key").Return(nil)
+
+	provider := flightprovider.NewMockFlightProvider(t)
+	provider.On("Search", mock.Anything, criteria.OutboundCriteria()).Return([]dto.Flight{
+		{ID: "outbound-1", Provider: "test-provider", Price: dto.Price{Amount: 1000000, Currency: "IDR"},
+			Departure: dto.Departure{Timestamp: 1000}, Arrival: dto.Arrival{Timestamp: 2000}},
+	}, nil)
+	provider.On("Search", mock.Anything, criteria.InboundCriteria()).Return([]dto.Flight{
+		{ID: "inbound-1", Provider: "test-provider", Price: dto.Price{Amount: 500000, Currency: "IDR"},
+			Departure: dto.Departure{Timestamp: 3000}, Arrival: dto.Arrival{Timestamp: 4000}},
+	}, nil)
+
+	factory := flightprovider.NewFlightProviderFactory()
+	factory.AddProvider("test-provider", provider)
+
+	saved := make(chan dto.SearchSession, 10)
+	sessions := NewMockSearchSessionStore(t)
+	sessions.EXPECT().SetSession(mock.Anything, mock.Anything, time.Minute).
+		Run(func(_ context.Context, session dto.SearchSession, _ time.Duration) {
+			saved <- session
+		}).Return(nil)
+
+	s := &AggregatorService{
+		ProviderFactory:       factory,
+		Cache:                 cache,
+		FlightCacheExpiration: 10 * time.Minute,
+		FlightLockTimeout:     5 * time.Second,
+		Rates:                 testRates(t),
+		Sessions:              sessions,
+		SessionExpiration:     time.Minute,
+	}
+
+	_, err := s.CreateSearch(context.Background(), criteria)
+	assert.NoError(t, err)
+
+	// created, one update once both legs of the provider answered, then completed
+	snapshots := make([]dto.SearchSession, 3)
+	for i := range snapshots {
+		select {
+		case snapshots[i] = <-saved:
+		case <-time.After(time.Second):
+			t.Fatalf("search session %d was not saved", i)
+		}
+	}
+
+	// the interim snapshot lists itineraries like the completed one, never one-way flights
+	for _, snapshot := range snapshots[1:] {
+		ids := []string{}
+		for _, itinerary := range snapshot.Itineraries {
+			ids = append(ids, itinerary.ID)
+		}
+
+		diff := cmp.Diff([]string{"outbound-1+inbound-1"}, ids)
+		if diff != "" {
+			t.Fatalf("CreateSearch() %s itineraries mismatch (-want +got):\n%s", snapshot.Status, diff)
+		}
+
+		assert.Empty(t, snapshot.Flights)
+		assert.Nil(t, snapshot.Facets)
+	}
+
+	assert.Equal(t, dto.SearchStatusPending, snapshots[1].Status)
+	assert.Equal(t, dto.SearchStatusCompleted, snapshots[2].Status)
+}
+
+func TestAggregatorService_GetSearch(t *testing.T) {
+	getSearchRequest := func(stored dto.SearchSession, storeErr error, want dto.SearchSession, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			sessions := NewMockSearchSessionStore(t)
+			sessions.On("GetSession", mock.Anything, "search-1").Return(stored, storeErr)
+
+			s := &AggregatorService{Sessions: sessions}
+
+			got, err := s.GetSearch(context.Background(), dto.SearchSessionRequest{ID: "search-1"})
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("expected error %v, got %v", wantErr, err)
+			}
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("GetSearch() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	session := dto.SearchSession{ID: "search-1", Status: dto.SearchStatusPending}
+
+	t.Run("found", getSearchRequest(session, nil, session, nil))
+	t.Run("expired", getSearchRequest(dto.SearchSession{}, flight.ErrSessionNotFound,
+		dto.SearchSession{}, ErrSearchNotFound))
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/airport"
+)
+
+type AirportService struct {
+	Directory *airport.Directory
+}
+
+func NewAirportService(directory *airport.Directory) *AirportService {
+	return &AirportService{
+		Directory: directory,
+	}
+}
+
+// SearchAirports autocompletes airports by code, city or name
+// SearchAirports godoc
+// @Summary      Search airports
+// @Tags         Airports
+// @Description  Autocomplete airports by IATA/ICAO code, city code, city or airport name
+// @Param        q      query     string  true   "Search query, at least 2 characters"
+// @Param        limit  query     int     false  "Maximum number of airports, default 10"
+// @Success      200    {object}  dto.AirportSearchResponse
+// @Failure      400    {object}  dto.ErrorResponse
+// @Failure      500    {object}  dto.ErrorResponse
+// @Router       /api/v1/airports [get]
+func (s *AirportService) SearchAirports(
+	_ context.Context,
+	req dto.AirportSearchRequest,
+) (dto.AirportSearchResponse, error) {
+	airports := s.Directory.Search(req.Query, req.Limit)
+
+	results := make([]dto.Airport, len(airports))
+	for i, a := range airports {
+		results[i] = dto.Airport{
+			IATA:      a.IATA,
+			ICAO:      a.ICAO,
+			Name:      a.Name,
+			City:      a.City,
+			CityCode:  a.CityCode,
+			Country:   a.Country,
+			Latitude:  a.Latitude,
+			Longitude: a.Longitude,
+			Timezone:  a.Timezone,
+		}
+	}
+
+	return dto.AirportSearchResponse{Airports: results}, nil
+}
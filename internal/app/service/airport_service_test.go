@@ -0,0 +1,59 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/airport"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAirportService_SearchAirports(t *testing.T) {
+	s := NewAirportService(airport.NewDirectory([]airport.Airport{
+		{
+			IATA:      "CGK",
+			ICAO:      "WIII",
+			Name:      "Soekarno-Hatta International Airport",
+			City:      "Jakarta",
+			CityCode:  "JKT",
+			Country:   "Indonesia",
+			Latitude:  -6.1256,
+			Longitude: 106.6559,
+			Timezone:  "Asia/Jakarta",
+		},
+		{IATA: "DPS", Name: "I Gusti Ngurah Rai International Airport", City: "Denpasar", CityCode: "DPS"},
+	}))
+
+	searchAirportsRequest := func(req dto.AirportSearchRequest, want dto.AirportSearchResponse) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := s.SearchAirports(context.Background(), req)
+			assert.NoError(t, err)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("SearchAirports() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("match", searchAirportsRequest(dto.AirportSearchRequest{Query: "jakarta", Limit: 10},
+		dto.AirportSearchResponse{Airports: []dto.Airport{
+			{
+				IATA:      "CGK",
+				ICAO:      "WIII",
+				Name:      "Soekarno-Hatta International Airport",
+				City:      "Jakarta",
+				CityCode:  "JKT",
+				Country:   "Indonesia",
+				Latitude:  -6.1256,
+				Longitude: 106.6559,
+				Timezone:  "Asia/Jakarta",
+			},
+		}}))
+	t.Run("no_match", searchAirportsRequest(dto.AirportSearchRequest{Query: "london", Limit: 10},
+		dto.AirportSearchResponse{Airports: []dto.Airport{}}))
+}
@@ -10,3 +10,23 @@ var ErrNoFlightsFound = exception.ApplicationError{
 	Message:    "no flights found",
 	StatusCode: http.StatusNotFound,
 }
+
+var ErrUnsupportedCurrency = exception.ApplicationError{
+	Message:    "display_currency is not supported",
+	StatusCode: http.StatusBadRequest,
+}
+
+var ErrSearchNotFound = exception.ApplicationError{
+	Message:    "search not found",
+	StatusCode: http.StatusNotFound,
+}
+
+var ErrInvalidCursor = exception.ApplicationError{
+	Message:    "cursor doesn't match the search",
+	StatusCode: http.StatusBadRequest,
+}
+
+var ErrCursorExpired = exception.ApplicationError{
+	Message:    "cursor has expired",
+	StatusCode: http.StatusGone,
+}
@@ -478,3 +478,159 @@ func (_c *MockFlightCacher_SetFlight_Call) RunAndReturn(run func(ctx context.Con
 	_c.Call.Return(run)
 	return _c
 }
+
+// NewMockSearchSessionStore creates a new instance of MockSearchSessionStore. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockSearchSessionStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockSearchSessionStore {
+	mock := &MockSearchSessionStore{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockSearchSessionStore is an autogenerated mock type for the SearchSessionStore type
+type MockSearchSessionStore struct {
+	mock.Mock
+}
+
+type MockSearchSessionStore_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockSearchSessionStore) EXPECT() *MockSearchSessionStore_Expecter {
+	return &MockSearchSessionStore_Expecter{mock: &_m.Mock}
+}
+
+// GetSession provides a mock function for the type MockSearchSessionStore
+func (_mock *MockSearchSessionStore) GetSession(ctx context.Context, id string) (dto.SearchSession, error) {
+	ret := _mock.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSession")
+	}
+
+	var r0 dto.SearchSession
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (dto.SearchSession, error)); ok {
+		return returnFunc(ctx, id)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) dto.SearchSession); ok {
+		r0 = returnFunc(ctx, id)
+	} else {
+		r0 = ret.Get(0).(dto.SearchSession)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockSearchSessionStore_GetSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSession'
+type MockSearchSessionStore_GetSession_Call struct {
+	*mock.Call
+}
+
+// GetSession is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id string
+func (_e *MockSearchSessionStore_Expecter) GetSession(ctx interface{}, id interface{}) *MockSearchSessionStore_GetSession_Call {
+	return &MockSearchSessionStore_GetSession_Call{Call: _e.mock.On("GetSession", ctx, id)}
+}
+
+func (_c *MockSearchSessionStore_GetSession_Call) Run(run func(ctx context.Context, id string)) *MockSearchSessionStore_GetSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 string
+		if args[1] != nil {
+			arg1 = args[1].(string)
+		}
+		run(
+			arg0,
+			arg1,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSearchSessionStore_GetSession_Call) Return(searchSession dto.SearchSession, err error) *MockSearchSessionStore_GetSession_Call {
+	_c.Call.Return(searchSession, err)
+	return _c
+}
+
+func (_c *MockSearchSessionStore_GetSession_Call) RunAndReturn(run func(ctx context.Context, id string) (dto.SearchSession, error)) *MockSearchSessionStore_GetSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetSession provides a mock function for the type MockSearchSessionStore
+func (_mock *MockSearchSessionStore) SetSession(ctx context.Context, session dto.SearchSession, expiration time.Duration) error {
+	ret := _mock.Called(ctx, session, expiration)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetSession")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, dto.SearchSession, time.Duration) error); ok {
+		r0 = returnFunc(ctx, session, expiration)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockSearchSessionStore_SetSession_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetSession'
+type MockSearchSessionStore_SetSession_Call struct {
+	*mock.Call
+}
+
+// SetSession is a helper method to define mock.On call
+//   - ctx context.Context
+//   - session dto.SearchSession
+//   - expiration time.Duration
+func (_e *MockSearchSessionStore_Expecter) SetSession(ctx interface{}, session interface{}, expiration interface{}) *MockSearchSessionStore_SetSession_Call {
+	return &MockSearchSessionStore_SetSession_Call{Call: _e.mock.On("SetSession", ctx, session, expiration)}
+}
+
+func (_c *MockSearchSessionStore_SetSession_Call) Run(run func(ctx context.Context, session dto.SearchSession, expiration time.Duration)) *MockSearchSessionStore_SetSession_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		var arg1 dto.SearchSession
+		if args[1] != nil {
+			arg1 = args[1].(dto.SearchSession)
+		}
+		var arg2 time.Duration
+		if args[2] != nil {
+			arg2 = args[2].(time.Duration)
+		}
+		run(
+			arg0,
+			arg1,
+			arg2,
+		)
+	})
+	return _c
+}
+
+func (_c *MockSearchSessionStore_SetSession_Call) Return(err error) *MockSearchSessionStore_SetSession_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockSearchSessionStore_SetSession_Call) RunAndReturn(run func(ctx context.Context, session dto.SearchSession, expiration time.Duration) error) *MockSearchSessionStore_SetSession_Call {
+	_c.Call.Return(run)
+	return _c
+}
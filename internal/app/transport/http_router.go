@@ -37,6 +37,47 @@ func MakeHTTPRouter(
 			httptransport.DecodeRequest[dto.SearchCriteria],
 			httptransport.ResponseWithBody,
 		))
+
+		streamSearch := httptransport.MakeHandlerFunc(
+			endpts.AggregatorEndpoint.StreamSearch,
+			httptransport.DecodeRequest[dto.SearchCriteria],
+			httptransport.ResponseWithEventStream,
+		)
+		router.Get("/search/stream", streamSearch)
+		router.Post("/search/stream", streamSearch)
+
+		router.Post("/searches", httptransport.MakeHandlerFunc(
+			endpts.AggregatorEndpoint.CreateSearch,
+			httptransport.DecodeRequest[dto.SearchCriteria],
+			httptransport.AcceptedResponse,
+		))
+
+		router.Get("/searches/{id}", httptransport.MakeHandlerFunc(
+			endpts.AggregatorEndpoint.GetSearch,
+			httptransport.DecodeRequest[dto.SearchSessionRequest],
+			httptransport.ResponseWithBody,
+		))
+
+		router.Post("/search/multi-city", httptransport.MakeHandlerFunc(
+			endpts.AggregatorEndpoint.SearchMultiCity,
+			httptransport.DecodeRequest[dto.MultiCitySearchCriteria],
+			httptransport.ResponseWithBody,
+		))
+	})
+
+	router.Route("/api/v1/airports", func(router chi.Router) {
+		router.Use(
+			httptransport.RequestID(),
+			httptransport.CORSMiddleware(),
+			httptransport.Recoverer(slog.Default()),
+			render.SetContentType(render.ContentTypeJSON),
+		)
+
+		router.Get("/", httptransport.MakeHandlerFunc(
+			endpts.AirportEndpoint.SearchAirports,
+			httptransport.DecodeRequest[dto.AirportSearchRequest],
+			httptransport.ResponseWithBody,
+		))
 	})
 
 	return router
@@ -0,0 +1,179 @@
+package airport
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed airports.json
+var embeddedAirports []byte
+
+// Airport is an airport of the reference data, CityCode groups the airports
+// serving the same metropolitan area, e.g. CGK and HLP are both JKT
+type Airport struct {
+	IATA      string  `json:"iata"`
+	ICAO      string  `json:"icao"`
+	Name      string  `json:"name"`
+	City      string  `json:"city"`
+	CityCode  string  `json:"city_code"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Timezone  string  `json:"timezone"`
+}
+
+// Directory resolves airport and city codes to airports
+type Directory struct {
+	airports map[string]Airport
+	icao     map[string]string
+	cities   map[string][]string
+}
+
+// Default is the directory of the embedded airport dataset,
+// it can be replaced at startup with LoadFile
+var Default = mustLoad(embeddedAirports)
+
+func NewDirectory(airports []Airport) *Directory {
+	d := &Directory{
+		airports: make(map[string]Airport, len(airports)),
+		icao:     make(map[string]string, len(airports)),
+		cities:   map[string][]string{},
+	}
+
+	for _, a := range airports {
+		d.airports[a.IATA] = a
+		if a.ICAO != "" {
+			d.icao[a.ICAO] = a.IATA
+		}
+
+		cityCode := a.CityCode
+		if cityCode == "" {
+			cityCode = a.IATA
+		}
+		d.cities[cityCode] = append(d.cities[cityCode], a.IATA)
+	}
+
+	for _, codes := range d.cities {
+		sort.Strings(codes)
+	}
+
+	return d
+}
+
+// Load reads a JSON array of airports
+func Load(r io.Reader) (*Directory, error) {
+	var airports []Airport
+	if err := json.NewDecoder(r).Decode(&airports); err != nil {
+		return nil, fmt.Errorf("failed to decode airports: %w", err)
+	}
+
+	for i, a := range airports {
+		if a.IATA == "" {
+			return nil, fmt.Errorf("airport %d: %w", i, errors.New("missing iata code"))
+		}
+	}
+
+	return NewDirectory(airports), nil
+}
+
+// LoadFile reads a JSON array of airports from a file
+func LoadFile(path string) (*Directory, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open airport file: %w", err)
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+func mustLoad(data []byte) *Directory {
+	d, err := Load(strings.NewReader(string(data)))
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// Lookup returns the airport of an IATA or ICAO airport code
+func (d *Directory) Lookup(code string) (Airport, bool) {
+	code = strings.ToUpper(code)
+	if iata, ok := d.icao[code]; ok {
+		code = iata
+	}
+
+	a, ok := d.airports[code]
+	return a, ok
+}
+
+// Expand resolves a city code to its airports, an airport code resolves to itself.
+// unknown codes are returned as is so they can still match exactly
+func (d *Directory) Expand(code string) []string {
+	code = strings.ToUpper(code)
+	if _, ok := d.airports[code]; ok {
+		return []string{code}
+	}
+
+	if codes, ok := d.cities[code]; ok {
+		return append([]string{}, codes...)
+	}
+
+	return []string{code}
+}
+
+// Resolve expands the code and adds every airport within radiusKm of the expanded airports
+func (d *Directory) Resolve(code string, radiusKm float64) []string {
+	expanded := d.Expand(code)
+	if radiusKm <= 0 {
+		return expanded
+	}
+
+	seen := map[string]bool{}
+	for _, c := range expanded {
+		seen[c] = true
+	}
+
+	for _, c := range expanded {
+		origin, ok := d.airports[c]
+		if !ok {
+			continue
+		}
+
+		for _, a := range d.airports {
+			if !seen[a.IATA] && distanceKm(origin, a) <= radiusKm {
+				seen[a.IATA] = true
+			}
+		}
+	}
+
+	resolved := make([]string, 0, len(seen))
+	for c := range seen {
+		resolved = append(resolved, c)
+	}
+	sort.Strings(resolved)
+
+	return resolved
+}
+
+const earthRadiusKm = 6371.0
+
+// distanceKm is the great-circle distance between two airports
+func distanceKm(a, b Airport) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
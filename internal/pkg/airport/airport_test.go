@@ -0,0 +1,80 @@
+//go:build unit
+
+package airport
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDirectory_Resolve_Closure(t *testing.T) {
+	resolveRequest := func(code string, radiusKm float64, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := Default.Resolve(code, radiusKm)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("Resolve() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("city_code", resolveRequest("JKT", 0, []string{"CGK", "HLP"}))
+	t.Run("lowercase_city_code", resolveRequest("jkt", 0, []string{"CGK", "HLP"}))
+	t.Run("airport_code", resolveRequest("CGK", 0, []string{"CGK"}))
+	t.Run("unknown_code", resolveRequest("XXX", 0, []string{"XXX"}))
+	t.Run("nearby_airports", resolveRequest("SIN", 50, []string{"BTH", "JHB", "SIN"}))
+	t.Run("nearby_airports_of_city", resolveRequest("JKT", 120, []string{"BDO", "CGK", "HLP"}))
+}
+
+func TestDirectory_Lookup_Closure(t *testing.T) {
+	lookupRequest := func(code string, wantIATA string, wantOK bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, ok := Default.Lookup(code)
+			if ok != wantOK {
+				t.Fatalf("Lookup() ok = %v, want %v", ok, wantOK)
+			}
+
+			if got.IATA != wantIATA {
+				t.Fatalf("Lookup() iata = %s, want %s", got.IATA, wantIATA)
+			}
+		}
+	}
+
+	t.Run("iata_code", lookupRequest("UPG", "UPG", true))
+	t.Run("icao_code", lookupRequest("waaa", "UPG", true))
+	t.Run("city_code_is_not_an_airport", lookupRequest("JKT", "", false))
+}
+
+func TestDirectory_Search_Closure(t *testing.T) {
+	directory := NewDirectory([]Airport{
+		{IATA: "CGK", ICAO: "WIII", Name: "Soekarno-Hatta International Airport", City: "Jakarta", CityCode: "JKT"},
+		{IATA: "HLP", ICAO: "WIHH", Name: "Halim Perdanakusuma International Airport", City: "Jakarta", CityCode: "JKT"},
+		{IATA: "JOG", ICAO: "WAHH", Name: "Adisutjipto International Airport", City: "Yogyakarta", CityCode: "JOG"},
+		{IATA: "KJT", ICAO: "WICA", Name: "Kertajati International Airport", City: "Majalengka", CityCode: "KJT"},
+	})
+
+	searchRequest := func(query string, limit int, wantIATA []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := directory.Search(query, limit)
+			gotIATA := make([]string, len(got))
+			for i, a := range got {
+				gotIATA[i] = a.IATA
+			}
+
+			diff := cmp.Diff(wantIATA, gotIATA)
+			if diff != "" {
+				t.Fatalf("Search() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("city_code", searchRequest("jkt", 10, []string{"CGK", "HLP"}))
+	t.Run("exact_code_before_name_match", searchRequest("jog", 10, []string{"JOG"}))
+	t.Run("city_prefix", searchRequest("jak", 10, []string{"CGK", "HLP"}))
+	t.Run("code_prefix_before_substring", searchRequest("k", 10, []string{"KJT", "CGK", "HLP", "JOG"}))
+	t.Run("name_substring", searchRequest("halim", 10, []string{"HLP"}))
+	t.Run("limit", searchRequest("international", 1, []string{"CGK"}))
+	t.Run("empty_query", searchRequest(" ", 10, []string{}))
+}
@@ -0,0 +1,76 @@
+package airport
+
+import (
+	"sort"
+	"strings"
+)
+
+// match tiers of the autocomplete, lower is better
+const (
+	matchCode = iota
+	matchCodePrefix
+	matchCityPrefix
+	matchNamePrefix
+	matchContains
+	noMatch
+)
+
+// Search returns up to limit airports matching the query for autocomplete.
+// exact codes come first, then code, city and name prefixes, then any substring
+func (d *Directory) Search(query string, limit int) []Airport {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" || limit <= 0 {
+		return []Airport{}
+	}
+
+	type match struct {
+		airport Airport
+		tier    int
+	}
+
+	var matches []match
+	for _, a := range d.airports {
+		if tier := matchTier(a, query); tier != noMatch {
+			matches = append(matches, match{airport: a, tier: tier})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].tier != matches[j].tier {
+			return matches[i].tier < matches[j].tier
+		}
+
+		return matches[i].airport.IATA < matches[j].airport.IATA
+	})
+
+	results := make([]Airport, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		results = append(results, matches[i].airport)
+	}
+
+	return results
+}
+
+func matchTier(a Airport, query string) int {
+	iata := strings.ToLower(a.IATA)
+	icao := strings.ToLower(a.ICAO)
+	cityCode := strings.ToLower(a.CityCode)
+	city := strings.ToLower(a.City)
+	name := strings.ToLower(a.Name)
+
+	switch {
+	case iata == query || icao == query || cityCode == query:
+		return matchCode
+	case strings.HasPrefix(iata, query) || strings.HasPrefix(icao, query):
+		return matchCodePrefix
+	case strings.HasPrefix(city, query):
+		return matchCityPrefix
+	case strings.HasPrefix(name, query):
+		return matchNamePrefix
+	case strings.Contains(city, query) || strings.Contains(name, query) ||
+		strings.Contains(strings.ToLower(a.Country), query):
+		return matchContains
+	}
+
+	return noMatch
+}
@@ -0,0 +1,107 @@
+package currency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency prices are compared in when no display currency is requested
+const DefaultCurrency = "IDR"
+
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// RateProvider returns the exchange rate to convert an amount from one currency to another
+type RateProvider interface {
+	Rate(ctx context.Context, from, to string) (float64, error)
+}
+
+// symbols of the currencies we sell in, others are prefixed with their code
+var symbols = map[string]string{
+	"IDR": "Rp",
+	"SGD": "S$",
+	"MYR": "RM",
+	"USD": "US$",
+}
+
+// currencies without minor units in practice
+var zeroDecimals = map[string]bool{
+	"IDR": true,
+	"JPY": true,
+}
+
+// Convert converts an amount with a rate and rounds it to the minor unit of the target currency
+func Convert(amount, rate float64, to string) float64 {
+	return Round(amount*rate, to)
+}
+
+// Round rounds an amount to the minor unit of the currency
+func Round(amount float64, code string) float64 {
+	if zeroDecimals[strings.ToUpper(code)] {
+		return math.Round(amount)
+	}
+
+	return math.Round(amount*100) / 100
+}
+
+// Format formats an amount with the symbol of its currency,
+// e.g. Rp1.234.567 or S$1,234.50
+func Format(amount float64, code string) string {
+	code = strings.ToUpper(code)
+
+	thousands, point := ",", "."
+	if code == "IDR" {
+		thousands, point = ".", ","
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	return Symbol(code) + sign + FormatAmount(amount, code, thousands, point)
+}
+
+// Symbol returns the symbol of a currency, currencies without one are prefixed with their code
+func Symbol(code string) string {
+	code = strings.ToUpper(code)
+	if symbol, ok := symbols[code]; ok {
+		return symbol
+	}
+
+	return code + " "
+}
+
+// FormatAmount formats the absolute amount rounded to the minor unit of the currency
+// with the given thousands separator and decimal point, without any symbol
+func FormatAmount(amount float64, code, thousands, point string) string {
+	decimals := 2
+	if zeroDecimals[strings.ToUpper(code)] {
+		decimals = 0
+	}
+
+	number := strconv.FormatFloat(Round(math.Abs(amount), code), 'f', decimals, 64)
+	whole, fraction, _ := strings.Cut(number, ".")
+
+	formatted := groupThousands(whole, thousands)
+	if fraction != "" {
+		formatted += point + fraction
+	}
+
+	return formatted
+}
+
+func groupThousands(digits, separator string) string {
+	var b strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(d)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,56 @@
+//go:build unit
+
+package currency
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormat_Closure(t *testing.T) {
+	formatRequest := func(amount float64, code, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, Format(amount, code)); diff != "" {
+				t.Fatalf("Format() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("rupiah", formatRequest(1234567, "IDR", "Rp1.234.567"))
+	t.Run("rupiah_zero", formatRequest(0, "IDR", "Rp0"))
+	t.Run("singapore_dollar", formatRequest(1234.5, "SGD", "S$1,234.50"))
+	t.Run("ringgit", formatRequest(98.765, "MYR", "RM98.77"))
+	t.Run("negative", formatRequest(-1500, "USD", "US$-1,500.00"))
+	t.Run("unknown_symbol", formatRequest(100, "EUR", "EUR 100.00"))
+}
+
+func TestFileRateProvider_Rate(t *testing.T) {
+	p, err := Load(strings.NewReader(`{"base": "IDR", "rates": {"SGD": 0.0001, "MYR": 0.0003}}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	rateRequest := func(from, to string, want float64, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := p.Rate(context.Background(), from, to)
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("Rate() error = %v, wantErr %v", err, wantErr)
+			}
+
+			if math.Abs(want-got) > 1e-9 {
+				t.Fatalf("Rate() = %v, want %v", got, want)
+			}
+		}
+	}
+
+	t.Run("from_base", rateRequest("IDR", "SGD", 0.0001, nil))
+	t.Run("to_base", rateRequest("SGD", "IDR", 10000, nil))
+	t.Run("cross_rate", rateRequest("SGD", "MYR", 3, nil))
+	t.Run("same_currency", rateRequest("THB", "THB", 1, nil))
+	t.Run("unsupported", rateRequest("IDR", "XXX", 0, ErrUnsupportedCurrency))
+}
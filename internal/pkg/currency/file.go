@@ -0,0 +1,90 @@
+package currency
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed rates.json
+var embeddedRates []byte
+
+// FileRateProvider serves exchange rates from a JSON file for offline use,
+// every rate is the amount of the currency bought by one unit of the base currency
+type FileRateProvider struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Default serves the embedded exchange rates, it can be replaced at startup with LoadFile
+var Default = mustLoad(embeddedRates)
+
+// Load reads exchange rates from JSON
+func Load(r io.Reader) (*FileRateProvider, error) {
+	var p FileRateProvider
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("failed to decode rates: %w", err)
+	}
+
+	if p.Base == "" {
+		return nil, errors.New("missing base currency")
+	}
+
+	rates := make(map[string]float64, len(p.Rates)+1)
+	for code, rate := range p.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate of %s must be positive", code)
+		}
+		rates[strings.ToUpper(code)] = rate
+	}
+	p.Base = strings.ToUpper(p.Base)
+	rates[p.Base] = 1
+	p.Rates = rates
+
+	return &p, nil
+}
+
+// LoadFile reads exchange rates from a JSON file
+func LoadFile(path string) (*FileRateProvider, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+func mustLoad(data []byte) *FileRateProvider {
+	p, err := Load(strings.NewReader(string(data)))
+	if err != nil {
+		panic(err)
+	}
+
+	return p
+}
+
+// Rate converts through the base currency
+func (p *FileRateProvider) Rate(_ context.Context, from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1, nil
+	}
+
+	fromRate, ok := p.Rates[from]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, from)
+	}
+
+	toRate, ok := p.Rates[to]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, to)
+	}
+
+	return toRate / fromRate, nil
+}
@@ -3,6 +3,7 @@ package flight
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -10,6 +11,9 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrSessionNotFound is returned for a search session that doesn't exist or has expired
+var ErrSessionNotFound = errors.New("search session not found")
+
 type RedisClient interface {
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
 	Del(ctx context.Context, keys ...string) *redis.IntCmd
@@ -28,13 +32,34 @@ func NewFlightCache(redis RedisClient) *FlightCache {
 }
 
 func (c *FlightCache) GetLockKey(req dto.SearchCriteria) string {
-	return fmt.Sprintf("flight:lock:%s:%s:%s:%s:%d",
-		req.DepartureDate, req.Origin, req.Destination, req.CabinClass, req.Passengers)
+	return "flight:lock:" + searchKey(req)
 }
 
 func (c *FlightCache) GetCacheKey(req dto.SearchCriteria) string {
-	return fmt.Sprintf("flight:cache:%s:%s:%s:%s:%d",
-		req.DepartureDate, req.Origin, req.Destination, req.CabinClass, req.Passengers)
+	return "flight:cache:" + searchKey(req)
+}
+
+// searchKey builds the part of the lock and cache key from every criteria
+// that changes what the providers return, fares are priced per passenger type
+// after the cache so only the seat count is part of the key
+func searchKey(req dto.SearchCriteria) string {
+	key := fmt.Sprintf("%s:%s:%s:%s:%d",
+		req.DepartureDate, req.Origin, req.Destination, req.CabinClass, req.SeatCount())
+
+	// round-trip results hold both legs so they can't share the one-way entry
+	if req.IsRoundTrip() {
+		key += ":" + req.ReturnDate
+	}
+
+	if req.IncludeHigherCabins {
+		key += ":higher"
+	}
+
+	if req.NearbyAirportsKm > 0 {
+		key += fmt.Sprintf(":nearby%d", req.NearbyAirportsKm)
+	}
+
+	return key
 }
 
 func (c *FlightCache) AcquireLock(ctx context.Context, key string, timeout time.Duration) (bool, error) {
@@ -102,3 +127,41 @@ func (c *FlightCache) GetMetadata(ctx context.Context, key string) (dto.Metadata
 
 	return metadata, nil
 }
+
+// GetSessionKey is the key of an asynchronous search session
+func (c *FlightCache) GetSessionKey(id string) string {
+	return "flight:session:" + id
+}
+
+// SetSession stores the whole state of the search session, the expiration restarts on every update
+func (c *FlightCache) SetSession(ctx context.Context, session dto.SearchSession, expiration time.Duration) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session: %w", err)
+	}
+
+	err = c.redis.Set(ctx, c.GetSessionKey(session.ID), data, expiration).Err()
+	if err != nil {
+		return fmt.Errorf("failed to set session: %w", err)
+	}
+
+	return nil
+}
+
+// GetSession returns the search session, ErrSessionNotFound when it doesn't exist or has expired
+func (c *FlightCache) GetSession(ctx context.Context, id string) (dto.SearchSession, error) {
+	data, err := c.redis.Get(ctx, c.GetSessionKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return dto.SearchSession{}, ErrSessionNotFound
+	}
+	if err != nil {
+		return dto.SearchSession{}, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	var session dto.SearchSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return dto.SearchSession{}, fmt.Errorf("failed to unmarshal session: %w", err)
+	}
+
+	return session, nil
+}
@@ -4,6 +4,7 @@ package flight
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -34,6 +35,37 @@ func TestFlightCache_GetLockKey_Closure(t *testing.T) {
 	t.Run("basic_lock_key", getLockKeyRequest(req, "flight:lock:2024-01-01:JKT:DPS:ECONOMY:1"))
 }
 
+func TestFlightCache_GetCacheKey_Closure(t *testing.T) {
+	getCacheKeyRequest := func(req dto.SearchCriteria, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			c := &FlightCache{}
+			got := c.GetCacheKey(req)
+			if got != want {
+				t.Fatalf("expected %s, got %s", want, got)
+			}
+		}
+	}
+
+	req := dto.SearchCriteria{
+		Origin:        "JKT",
+		Destination:   "DPS",
+		DepartureDate: "2024-01-01",
+		CabinClass:    "ECONOMY",
+		Passengers:    1,
+	}
+	roundTripReq := req
+	roundTripReq.ReturnDate = "2024-01-05"
+	nearbyReq := req
+	nearbyReq.NearbyAirportsKm = 100
+	higherCabinsReq := req
+	higherCabinsReq.IncludeHigherCabins = true
+
+	t.Run("one_way_cache_key", getCacheKeyRequest(req, "flight:cache:2024-01-01:JKT:DPS:ECONOMY:1"))
+	t.Run("round_trip_cache_key", getCacheKeyRequest(roundTripReq, "flight:cache:2024-01-01:JKT:DPS:ECONOMY:1:2024-01-05"))
+	t.Run("nearby_airports_cache_key", getCacheKeyRequest(nearbyReq, "flight:cache:2024-01-01:JKT:DPS:ECONOMY:1:nearby100"))
+	t.Run("higher_cabins_cache_key", getCacheKeyRequest(higherCabinsReq, "flight:cache:2024-01-01:JKT:DPS:ECONOMY:1:higher"))
+}
+
 func TestFlightCache_AcquireLock_Closure(t *testing.T) {
 	acquireLockRequest := func(key string, timeout time.Duration, mockSetup func(m *MockRedisClient), want bool) func(t *testing.T) {
 		return func(t *testing.T) {
@@ -112,3 +144,49 @@ func TestFlightCache_GetFlight_Closure(t *testing.T) {
 		m.On("Get", mock.Anything, "test-cache").Return(redis.NewStringResult("", redis.Nil))
 	}, nil, true))
 }
+
+func TestFlightCache_Session_Closure(t *testing.T) {
+	session := dto.SearchSession{
+		ID:               "search-1",
+		Status:           dto.SearchStatusPending,
+		PendingProviders: []string{"Garuda"},
+		Flights:          []dto.Flight{{ID: "1"}},
+	}
+
+	t.Run("set_session", func(t *testing.T) {
+		m := NewMockRedisClient(t)
+		m.On("Set", mock.Anything, "flight:session:search-1", mock.Anything, 10*time.Minute).
+			Return(redis.NewStatusResult("OK", nil))
+
+		err := NewFlightCache(m).SetSession(context.Background(), session, 10*time.Minute)
+		if err != nil {
+			t.Fatalf("SetSession returned error: %v", err)
+		}
+	})
+
+	getSessionRequest := func(mockSetup func(m *MockRedisClient), want dto.SearchSession, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			m := NewMockRedisClient(t)
+			mockSetup(m)
+
+			got, err := NewFlightCache(m).GetSession(context.Background(), "search-1")
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("GetSession error = %v, wantErr %v", err, wantErr)
+			}
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("GetSession mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("get_session", getSessionRequest(func(m *MockRedisClient) {
+		m.On("Get", mock.Anything, "flight:session:search-1").Return(redis.NewStringResult(
+			`{"id":"search-1","status":"pending","pending_providers":["Garuda"],"flights":[{"id":"1"}]}`, nil))
+	}, session, nil))
+
+	t.Run("expired_session", getSessionRequest(func(m *MockRedisClient) {
+		m.On("Get", mock.Anything, "flight:session:search-1").Return(redis.NewStringResult("", redis.Nil))
+	}, dto.SearchSession{}, ErrSessionNotFound))
+}
@@ -0,0 +1,27 @@
+package flight
+
+import "github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+
+// BuildCalendarDay summarizes the lowest price, fastest duration and
+// number of flights departing on date
+func BuildCalendarDay(date string, flights []dto.Flight) dto.CalendarDay {
+	day := dto.CalendarDay{
+		Date:         date,
+		TotalResults: len(flights),
+	}
+
+	for i := range flights {
+		if day.LowestPrice == nil || flights[i].Price.Amount < day.LowestPrice.Amount {
+			price := flights[i].Price
+			day.LowestPrice = &price
+		}
+
+		if day.FastestDuration == nil ||
+			flights[i].Duration.TotalMinutes < day.FastestDuration.TotalMinutes {
+			duration := flights[i].Duration
+			day.FastestDuration = &duration
+		}
+	}
+
+	return day
+}
@@ -0,0 +1,46 @@
+//go:build unit
+
+package flight
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestBuildCalendarDay_Closure(t *testing.T) {
+	buildRequest := func(flights []dto.Flight, want dto.CalendarDay) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := BuildCalendarDay("2024-01-01", flights)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("BuildCalendarDay result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("lowest_price_and_fastest_duration_from_different_flights", buildRequest(
+		[]dto.Flight{
+			{
+				ID:       "FAST",
+				Price:    dto.Price{Amount: 900000, Currency: "IDR", Formatted: "Rp900.000"},
+				Duration: dto.Duration{TotalMinutes: 90, Formatted: "1h 30m"},
+			},
+			{
+				ID:       "CHEAP",
+				Price:    dto.Price{Amount: 500000, Currency: "IDR", Formatted: "Rp500.000"},
+				Duration: dto.Duration{TotalMinutes: 240, Formatted: "4h"},
+			},
+		},
+		dto.CalendarDay{
+			Date:            "2024-01-01",
+			LowestPrice:     &dto.Price{Amount: 500000, Currency: "IDR", Formatted: "Rp500.000"},
+			FastestDuration: &dto.Duration{TotalMinutes: 90, Formatted: "1h 30m"},
+			TotalResults:    2,
+		},
+	))
+
+	t.Run("no_flights", buildRequest(nil, dto.CalendarDay{Date: "2024-01-01"}))
+}
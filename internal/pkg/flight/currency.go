@@ -0,0 +1,91 @@
+package flight
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+)
+
+// ConvertPrices converts the price and offers of every flight to one currency so
+// mixed-currency results can be filtered, ranked and sorted together.
+// flights priced in a currency without a rate are dropped
+func ConvertPrices(ctx context.Context, flights []dto.Flight,
+	rates currency.RateProvider, to string) []dto.Flight {
+	converter := priceConverter{rates: rates, to: to, cache: map[string]float64{}}
+
+	converted := make([]dto.Flight, 0, len(flights))
+	for _, f := range flights {
+		price, err := converter.convert(ctx, f.Price)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to convert flight price",
+				slog.String("flight", f.ID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		f.Price = price
+
+		if len(f.Offers) > 0 {
+			offers := make([]dto.Offer, 0, len(f.Offers))
+			for _, offer := range f.Offers {
+				if offer.Price, err = converter.convert(ctx, offer.Price); err == nil {
+					offers = append(offers, offer)
+				}
+			}
+			f.Offers = offers
+		}
+
+		converted = append(converted, f)
+	}
+
+	return converted
+}
+
+// priceConverter keeps the rates of one search so every currency pair is only fetched once
+type priceConverter struct {
+	rates currency.RateProvider
+	to    string
+	cache map[string]float64
+}
+
+func (c priceConverter) convert(ctx context.Context, price dto.Price) (dto.Price, error) {
+	// prices without a currency are in rupiah like every provider we started with
+	from := price.Currency
+	if from == "" {
+		from = currency.DefaultCurrency
+	}
+
+	if from == c.to {
+		return price, nil
+	}
+
+	rate, ok := c.cache[from]
+	if !ok {
+		var err error
+		rate, err = c.rates.Rate(ctx, from, c.to)
+		if err != nil {
+			return dto.Price{}, err
+		}
+		c.cache[from] = rate
+	}
+
+	converted := dto.Price{
+		Amount:   currency.Convert(price.Amount, rate, c.to),
+		Currency: c.to,
+	}
+	converted.Formatted = currency.Format(converted.Amount, c.to)
+
+	if price.Breakdown != nil {
+		breakdown := *price.Breakdown
+		breakdown.Taxes = currency.Convert(breakdown.Taxes, rate, c.to)
+		breakdown.Surcharges = currency.Convert(breakdown.Surcharges, rate, c.to)
+		breakdown.ServiceFees = currency.Convert(breakdown.ServiceFees, rate, c.to)
+		// the components take the rounding so they still add up to the amount
+		breakdown.BaseFare = currency.Round(converted.Amount-breakdown.Taxes-
+			breakdown.Surcharges-breakdown.ServiceFees, c.to)
+		converted.Breakdown = &breakdown
+	}
+
+	return converted, nil
+}
@@ -0,0 +1,81 @@
+//go:build unit
+
+package flight
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+)
+
+func TestConvertPrices_Closure(t *testing.T) {
+	rates, err := currency.Load(strings.NewReader(`{"base": "IDR", "rates": {"SGD": 0.0001, "MYR": 0.0003}}`))
+	if err != nil {
+		t.Fatalf("failed to load rates: %v", err)
+	}
+
+	convertRequest := func(flights []dto.Flight, to string, want []dto.Flight) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := ConvertPrices(context.Background(), flights, rates, to)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("ConvertPrices result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("mixed_currencies_to_rupiah", convertRequest(
+		[]dto.Flight{
+			{ID: "IDR", Price: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"}},
+			{ID: "SGD", Price: dto.Price{Amount: 50, Currency: "SGD", Formatted: "S$50.00"}},
+		},
+		"IDR",
+		[]dto.Flight{
+			{ID: "IDR", Price: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"}},
+			{ID: "SGD", Price: dto.Price{Amount: 500000, Currency: "IDR", Formatted: "Rp500.000"}},
+		},
+	))
+
+	t.Run("breakdown_adds_up_after_rounding", convertRequest(
+		[]dto.Flight{
+			{ID: "IDR", Price: dto.Price{
+				Amount: 1110000, Currency: "IDR",
+				Breakdown: &dto.FareBreakdown{BaseFare: 1000000, Taxes: 110000, Reported: true},
+			}},
+		},
+		"MYR",
+		[]dto.Flight{
+			{ID: "IDR", Price: dto.Price{
+				Amount: 333, Currency: "MYR", Formatted: "RM333.00",
+				Breakdown: &dto.FareBreakdown{BaseFare: 300, Taxes: 33, Reported: true},
+			}},
+		},
+	))
+
+	t.Run("offers_are_converted", convertRequest(
+		[]dto.Flight{
+			{ID: "GA-1", Price: dto.Price{Amount: 800000, Currency: "IDR"}, Offers: []dto.Offer{
+				{ID: "GA-1", Price: dto.Price{Amount: 800000, Currency: "IDR"}},
+			}},
+		},
+		"SGD",
+		[]dto.Flight{
+			{ID: "GA-1", Price: dto.Price{Amount: 80, Currency: "SGD", Formatted: "S$80.00"}, Offers: []dto.Offer{
+				{ID: "GA-1", Price: dto.Price{Amount: 80, Currency: "SGD", Formatted: "S$80.00"}},
+			}},
+		},
+	))
+
+	t.Run("unknown_currency_is_dropped", convertRequest(
+		[]dto.Flight{
+			{ID: "THB", Price: dto.Price{Amount: 2000, Currency: "THB"}},
+		},
+		"IDR",
+		[]dto.Flight{},
+	))
+}
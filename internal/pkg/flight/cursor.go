@@ -0,0 +1,86 @@
+package flight
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// ErrInvalidCursor is returned for a cursor that can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points to the next page of a search. Key is the snapshot of the flight set the first page
+// was served from, Fingerprint the filter and sort of the search and Offset the first flight of the page
+type Cursor struct {
+	Key         string `json:"k"`
+	Fingerprint string `json:"f"`
+	Offset      int    `json:"o"`
+}
+
+// EncodeCursor returns the opaque cursor sent to the client
+func EncodeCursor(cursor Cursor) string {
+	//nolint:errchkjson // a struct of strings and an int always marshals
+	data, _ := json.Marshal(cursor)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor returned by EncodeCursor
+func DecodeCursor(encoded string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return Cursor{}, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+
+	if cursor.Key == "" || cursor.Offset < 0 {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
+
+// SearchFingerprint hashes everything that filters or orders the flights of a search once they are
+// fetched, a cursor only continues a search with the same fingerprint
+func SearchFingerprint(req dto.SearchCriteria) string {
+	//nolint:errchkjson // the options only hold strings, numbers and booleans
+	data, _ := json.Marshal(struct {
+		Filter     *dto.FilterOption
+		Sort       *dto.SortOption
+		Currency   string
+		Passengers dto.PassengerTypes
+	}{req.FilterOption, req.SortOption, req.TargetCurrency(), req.PassengerCounts()})
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// SnapshotKey returns the key of the flight set a paginated search is served from, it hashes the
+// flights of the cache entry so the searches served from the same entry share one snapshot
+func SnapshotKey(cacheKey string, flights []dto.Flight) string {
+	//nolint:errchkjson // flights are decoded from the cache or the providers so they marshal
+	data, _ := json.Marshal(flights)
+
+	sum := sha256.Sum256(data)
+
+	return cacheKey + ":page:" + hex.EncodeToString(sum[:8])
+}
+
+// Paginate returns the page of flights starting at offset
+func Paginate(flights []dto.Flight, offset, size int) []dto.Flight {
+	if offset >= len(flights) {
+		return []dto.Flight{}
+	}
+
+	return flights[offset:min(offset+size, len(flights))]
+}
@@ -0,0 +1,112 @@
+//go:build unit
+
+package flight
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestCursor_Closure(t *testing.T) {
+	t.Run("round_trip", func(t *testing.T) {
+		cursor := Cursor{Key: "flight:cache:2024-01-01:JKT:DPS:economy:1:page:1", Fingerprint: "abc", Offset: 20}
+
+		got, err := DecodeCursor(EncodeCursor(cursor))
+		if err != nil {
+			t.Fatalf("DecodeCursor returned error: %v", err)
+		}
+
+		diff := cmp.Diff(cursor, got)
+		if diff != "" {
+			t.Fatalf("DecodeCursor mismatch (-want +got):\n%s", diff)
+		}
+	})
+
+	decodeRequest := func(encoded string) func(t *testing.T) {
+		return func(t *testing.T) {
+			_, err := DecodeCursor(encoded)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Fatalf("expected error %v, got %v", ErrInvalidCursor, err)
+			}
+		}
+	}
+
+	t.Run("not_base64", decodeRequest("not a cursor"))
+	t.Run("not_json", decodeRequest("bm90IGpzb24"))
+	t.Run("negative_offset", decodeRequest(EncodeCursor(Cursor{Key: "key", Offset: -1})))
+}
+
+func TestSearchFingerprint_Closure(t *testing.T) {
+	maxStops := 0
+	req := dto.SearchCriteria{Origin: "JKT", Destination: "DPS", Passengers: 1}
+
+	sameSearch := req
+	sameSearch.Cursor = "cursor"
+	sameSearch.PageSize = 10
+
+	filtered := req
+	filtered.FilterOption = &dto.FilterOption{MaxStops: &maxStops}
+
+	sorted := req
+	sorted.SortOption = &dto.SortOption{Field: "price", Order: "asc"}
+
+	if SearchFingerprint(req) != SearchFingerprint(sameSearch) {
+		t.Fatalf("expected the cursor and page size to keep the fingerprint")
+	}
+
+	if SearchFingerprint(req) == SearchFingerprint(filtered) {
+		t.Fatalf("expected the filter to change the fingerprint")
+	}
+
+	if SearchFingerprint(req) == SearchFingerprint(sorted) {
+		t.Fatalf("expected the sort to change the fingerprint")
+	}
+}
+
+func TestSnapshotKey(t *testing.T) {
+	flights := []dto.Flight{{ID: "1"}, {ID: "2"}}
+	refreshed := []dto.Flight{{ID: "1"}, {ID: "3"}}
+
+	key := SnapshotKey("flight:cache:key", flights)
+
+	if diff := cmp.Diff("flight:cache:key:page:", key[:len(key)-16]); diff != "" {
+		t.Fatalf("SnapshotKey prefix mismatch (-want +got):\n%s", diff)
+	}
+
+	if key != SnapshotKey("flight:cache:key", []dto.Flight{{ID: "1"}, {ID: "2"}}) {
+		t.Fatalf("expected the same cache entry to share the snapshot")
+	}
+
+	if key == SnapshotKey("flight:cache:key", refreshed) {
+		t.Fatalf("expected a refreshed cache entry to get its own snapshot")
+	}
+
+	if key == SnapshotKey("flight:cache:other", flights) {
+		t.Fatalf("expected another search to get its own snapshot")
+	}
+}
+
+func TestPaginate_Closure(t *testing.T) {
+	paginateRequest := func(offset, size int, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			flights := []dto.Flight{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+			got := []string{}
+			for _, f := range Paginate(flights, offset, size) {
+				got = append(got, f.ID)
+			}
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("Paginate mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("first_page", paginateRequest(0, 2, []string{"1", "2"}))
+	t.Run("last_page", paginateRequest(2, 2, []string{"3"}))
+	t.Run("past_the_end", paginateRequest(3, 2, []string{}))
+}
@@ -0,0 +1,182 @@
+package flight
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// BuildFacets counts the unfiltered flights by airline, stops, price, departure time and duration.
+// faceting is disjunctive, every facet is counted on the flights matching the other active filters
+// so selecting a value of a facet doesn't hide its other values
+func BuildFacets(ctx context.Context, flights []dto.Flight, filterOpts *dto.FilterOption) *dto.Facets {
+	return &dto.Facets{
+		Airlines: airlineFacets(FilterFlights(ctx, flights, withoutFilter(filterOpts, func(f *dto.FilterOption) {
+			f.Airline = nil
+		}))),
+		Stops: stopsFacets(FilterFlights(ctx, flights, withoutFilter(filterOpts, func(f *dto.FilterOption) {
+			f.MinStops, f.MaxStops = nil, nil
+		}))),
+		Prices: priceFacets(FilterFlights(ctx, flights, withoutFilter(filterOpts, func(f *dto.FilterOption) {
+			f.MinPrice, f.MaxPrice = nil, nil
+		}))),
+		DepartureTimes: departureTimeFacets(FilterFlights(ctx, flights, withoutFilter(filterOpts, func(f *dto.FilterOption) {
+			f.DepartureTimeStart, f.DepartureTimeEnd = nil, nil
+		}))),
+		Duration: durationFacet(FilterFlights(ctx, flights, withoutFilter(filterOpts, func(f *dto.FilterOption) {
+			f.MinDurationMinutes, f.MaxDurationMinutes = nil, nil
+		}))),
+	}
+}
+
+// withoutFilter returns a copy of the filter option with the filter of a facet cleared
+func withoutFilter(filterOpts *dto.FilterOption, clear func(f *dto.FilterOption)) *dto.FilterOption {
+	if filterOpts == nil {
+		return nil
+	}
+
+	others := *filterOpts
+	clear(&others)
+
+	return &others
+}
+
+// airlineFacets counts the flights of every airline, the airline with the most flights first.
+// like the airline filter a codeshare flight counts for both its marketing and its operating carrier
+func airlineFacets(flights []dto.Flight) []dto.AirlineFacet {
+	facets := []dto.AirlineFacet{}
+	index := map[string]int{}
+
+	for _, f := range flights {
+		carriers := []dto.Airline{f.Airline}
+		if f.OperatingAirline.Code != "" && f.OperatingAirline.Code != f.Airline.Code {
+			carriers = append(carriers, f.OperatingAirline)
+		}
+
+		for _, airline := range carriers {
+			i, ok := index[airline.Code]
+			if !ok {
+				index[airline.Code] = len(facets)
+				facets = append(facets, dto.AirlineFacet{Airline: airline, Count: 1, MinPrice: f.Price})
+				continue
+			}
+
+			facets[i].Count++
+			if f.Price.Amount < facets[i].MinPrice.Amount {
+				facets[i].MinPrice = f.Price
+			}
+		}
+	}
+
+	sort.SliceStable(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+
+		return facets[i].Airline.Code < facets[j].Airline.Code
+	})
+
+	return facets
+}
+
+// stopsFacets counts the flights by number of stops, direct flights first
+func stopsFacets(flights []dto.Flight) []dto.StopsFacet {
+	counts := map[int]int{}
+	for _, f := range flights {
+		counts[f.Stops]++
+	}
+
+	facets := make([]dto.StopsFacet, 0, len(counts))
+	for stops, count := range counts {
+		facets = append(facets, dto.StopsFacet{Stops: stops, Count: count})
+	}
+
+	sort.Slice(facets, func(i, j int) bool {
+		return facets[i].Stops < facets[j].Stops
+	})
+
+	return facets
+}
+
+// priceFacets splits the prices into buckets of the same width from the lowest to the highest price,
+// rounded to whole currency units. every bucket is returned even when it is empty
+func priceFacets(flights []dto.Flight) []dto.PriceFacet {
+	if len(flights) == 0 {
+		return []dto.PriceFacet{}
+	}
+
+	lowest, highest := math.Inf(1), math.Inf(-1)
+	for _, f := range flights {
+		lowest = min(lowest, f.Price.Amount)
+		highest = max(highest, f.Price.Amount)
+	}
+	lowest, highest = math.Floor(lowest), math.Ceil(highest)
+
+	// every flight has the same price
+	if highest == lowest {
+		return []dto.PriceFacet{{Min: lowest, Max: highest, Count: len(flights)}}
+	}
+
+	width := math.Ceil((highest - lowest) / dto.PriceFacetBuckets)
+
+	facets := make([]dto.PriceFacet, 0, dto.PriceFacetBuckets)
+	for i := 0; i < dto.PriceFacetBuckets; i++ {
+		facets = append(facets, dto.PriceFacet{
+			Min: lowest + float64(i)*width,
+			Max: min(lowest+float64(i+1)*width, highest),
+		})
+	}
+
+	for _, f := range flights {
+		i := min(int((f.Price.Amount-lowest)/width), len(facets)-1)
+		facets[i].Count++
+	}
+
+	// rounding up the width can leave buckets past the highest price
+	for len(facets) > 1 && facets[len(facets)-1].Min >= highest {
+		facets = facets[:len(facets)-1]
+	}
+
+	return facets
+}
+
+// departureTimeFacets counts the flights departing in every part of the day, in the local time of the departure
+func departureTimeFacets(flights []dto.Flight) []dto.DepartureTimeFacet {
+	facets := make([]dto.DepartureTimeFacet, len(dto.DepartureTimeBuckets))
+	copy(facets, dto.DepartureTimeBuckets)
+
+	for _, f := range flights {
+		departure, err := time.Parse(time.RFC3339, f.Departure.Datetime)
+		if err != nil {
+			continue
+		}
+
+		// the buckets are 6 hours long
+		facets[departure.Hour()/6].Count++
+	}
+
+	return facets
+}
+
+// durationFacet returns the shortest and longest duration, nil when there is no flight
+func durationFacet(flights []dto.Flight) *dto.DurationFacet {
+	if len(flights) == 0 {
+		return nil
+	}
+
+	facet := &dto.DurationFacet{Min: flights[0].Duration, Max: flights[0].Duration}
+	for _, f := range flights[1:] {
+		if f.Duration.TotalMinutes < facet.Min.TotalMinutes {
+			facet.Min = f.Duration
+		}
+
+		if f.Duration.TotalMinutes > facet.Max.TotalMinutes {
+			facet.Max = f.Duration
+		}
+	}
+
+	return facet
+}
@@ -0,0 +1,135 @@
+//go:build unit
+
+package flight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestBuildFacets_Closure(t *testing.T) {
+	garuda := dto.Airline{Name: "Garuda Indonesia", Code: "GA"}
+	airAsia := dto.Airline{Name: "AirAsia", Code: "QZ"}
+	lionAir := dto.Airline{Name: "Lion Air", Code: "JT"}
+
+	newFlight := func(airline dto.Airline, price float64, stops int, departure string, minutes int) dto.Flight {
+		return dto.Flight{
+			Airline:   airline,
+			Price:     dto.Price{Amount: price, Currency: "IDR"},
+			Stops:     stops,
+			Departure: dto.Departure{Datetime: departure},
+			Duration:  dto.Duration{TotalMinutes: minutes},
+		}
+	}
+
+	flights := []dto.Flight{
+		newFlight(garuda, 1000000, 0, "2025-12-15T06:30:00+07:00", 120),
+		newFlight(garuda, 1500000, 1, "2025-12-15T13:00:00+07:00", 240),
+		newFlight(airAsia, 600000, 0, "2025-12-15T05:00:00+07:00", 110),
+		newFlight(lionAir, 2000000, 2, "2025-12-15T19:00:00+07:00", 400),
+	}
+
+	departureTimes := func(counts ...int) []dto.DepartureTimeFacet {
+		facets := make([]dto.DepartureTimeFacet, len(dto.DepartureTimeBuckets))
+		copy(facets, dto.DepartureTimeBuckets)
+		for i, count := range counts {
+			facets[i].Count = count
+		}
+
+		return facets
+	}
+
+	buildRequest := func(flights []dto.Flight, filterOpts *dto.FilterOption, want *dto.Facets) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := BuildFacets(context.Background(), flights, filterOpts)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("BuildFacets result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("no_filter", buildRequest(flights, nil, &dto.Facets{
+		Airlines: []dto.AirlineFacet{
+			{Airline: garuda, Count: 2, MinPrice: dto.Price{Amount: 1000000, Currency: "IDR"}},
+			{Airline: lionAir, Count: 1, MinPrice: dto.Price{Amount: 2000000, Currency: "IDR"}},
+			{Airline: airAsia, Count: 1, MinPrice: dto.Price{Amount: 600000, Currency: "IDR"}},
+		},
+		Stops: []dto.StopsFacet{
+			{Stops: 0, Count: 2},
+			{Stops: 1, Count: 1},
+			{Stops: 2, Count: 1},
+		},
+		Prices: []dto.PriceFacet{
+			{Min: 600000, Max: 880000, Count: 1},
+			{Min: 880000, Max: 1160000, Count: 1},
+			{Min: 1160000, Max: 1440000, Count: 0},
+			{Min: 1440000, Max: 1720000, Count: 1},
+			{Min: 1720000, Max: 2000000, Count: 1},
+		},
+		DepartureTimes: departureTimes(1, 1, 1, 1),
+		Duration:       &dto.DurationFacet{Min: dto.Duration{TotalMinutes: 110}, Max: dto.Duration{TotalMinutes: 400}},
+	}))
+
+	airlineGaruda := "GA"
+	direct := 0
+	t.Run("every_facet_ignores_its_own_filter", buildRequest(flights, &dto.FilterOption{
+		Airline:  &airlineGaruda,
+		MaxStops: &direct,
+	}, &dto.Facets{
+		// direct flights of every airline
+		Airlines: []dto.AirlineFacet{
+			{Airline: garuda, Count: 1, MinPrice: dto.Price{Amount: 1000000, Currency: "IDR"}},
+			{Airline: airAsia, Count: 1, MinPrice: dto.Price{Amount: 600000, Currency: "IDR"}},
+		},
+		// garuda flights with any stops
+		Stops: []dto.StopsFacet{
+			{Stops: 0, Count: 1},
+			{Stops: 1, Count: 1},
+		},
+		Prices:         []dto.PriceFacet{{Min: 1000000, Max: 1000000, Count: 1}},
+		DepartureTimes: departureTimes(0, 1, 0, 0),
+		Duration:       &dto.DurationFacet{Min: dto.Duration{TotalMinutes: 120}, Max: dto.Duration{TotalMinutes: 120}},
+	}))
+
+	t.Run("no_flights", buildRequest(nil, nil, &dto.Facets{
+		Airlines:       []dto.AirlineFacet{},
+		Stops:          []dto.StopsFacet{},
+		Prices:         []dto.PriceFacet{},
+		DepartureTimes: departureTimes(),
+	}))
+}
+
+func TestAirlineFacets_Codeshare(t *testing.T) {
+	garuda := dto.Airline{Name: "Garuda Indonesia", Code: "GA"}
+	citilink := dto.Airline{Name: "Citilink", Code: "QG"}
+
+	flights := []dto.Flight{
+		{ID: "codeshare", Airline: garuda, OperatingAirline: citilink, Price: dto.Price{Amount: 900000, Currency: "IDR"}},
+		{ID: "citilink", Airline: citilink, OperatingAirline: citilink, Price: dto.Price{Amount: 700000, Currency: "IDR"}},
+		{ID: "garuda", Airline: garuda, Price: dto.Price{Amount: 1200000, Currency: "IDR"}},
+	}
+
+	// the codeshare counts for both carriers, like the airline filter matches it
+	want := []dto.AirlineFacet{
+		{Airline: garuda, Count: 2, MinPrice: dto.Price{Amount: 900000, Currency: "IDR"}},
+		{Airline: citilink, Count: 2, MinPrice: dto.Price{Amount: 700000, Currency: "IDR"}},
+	}
+
+	got := airlineFacets(flights)
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("airlineFacets result mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, facet := range got {
+		code := facet.Airline.Code
+		filtered := FilterFlights(context.Background(), flights, &dto.FilterOption{Airline: &code})
+		if diff := cmp.Diff(facet.Count, len(filtered)); diff != "" {
+			t.Fatalf("airlineFacets count of %s doesn't match the filter (-want +got):\n%s", code, diff)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package flight
 import (
 	"context"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
@@ -16,7 +17,9 @@ func FilterFlights(ctx context.Context, flights []dto.Flight, filterOpts *dto.Fi
 	results := make([]dto.Flight, 0, len(flights))
 
 	for _, flight := range flights {
-		if filterOpts.Airline != nil && *filterOpts.Airline != flight.Airline.Code {
+		// airline matches the marketing or the operating carrier
+		if filterOpts.Airline != nil && *filterOpts.Airline != flight.Airline.Code &&
+			*filterOpts.Airline != flight.OperatingAirline.Code {
 			continue
 		}
 
@@ -44,6 +47,18 @@ func FilterFlights(ctx context.Context, flights []dto.Flight, filterOpts *dto.Fi
 			continue
 		}
 
+		if !matchLayovers(flight.Layovers, filterOpts) {
+			continue
+		}
+
+		if !matchBaggage(flight.Baggage, filterOpts) {
+			continue
+		}
+
+		if !hasAmenities(flight.Amenities, filterOpts.Amenities) {
+			continue
+		}
+
 		if filterOpts.DepartureTimeStart != nil && filterOpts.DepartureTimeEnd != nil {
 			if !isWithinTimeRange(ctx, flight.Departure.Datetime, *filterOpts.DepartureTimeStart, *filterOpts.DepartureTimeEnd) {
 				continue
@@ -62,6 +77,82 @@ func FilterFlights(ctx context.Context, flights []dto.Flight, filterOpts *dto.Fi
 	return results
 }
 
+// matchLayovers checks the layover duration of every connection and the layover airports,
+// direct flights have no layover so they only fail when a layover airport is required
+func matchLayovers(layovers []dto.Layover, filterOpts *dto.FilterOption) bool {
+	for _, layover := range layovers {
+		if filterOpts.MinLayoverMinutes != nil && layover.Duration.TotalMinutes < *filterOpts.MinLayoverMinutes {
+			return false
+		}
+
+		if filterOpts.MaxLayoverMinutes != nil && layover.Duration.TotalMinutes > *filterOpts.MaxLayoverMinutes {
+			return false
+		}
+
+		if containsAirport(filterOpts.ExcludeLayoverAirports, layover.Airport) {
+			return false
+		}
+	}
+
+	if len(filterOpts.IncludeLayoverAirports) == 0 {
+		return true
+	}
+
+	for _, layover := range layovers {
+		if containsAirport(filterOpts.IncludeLayoverAirports, layover.Airport) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchBaggage checks the free checked baggage, a weight filter needs the weight
+// so flights only reporting pieces don't match it
+func matchBaggage(baggage dto.Baggage, filterOpts *dto.FilterOption) bool {
+	if filterOpts.CheckedBaggageIncluded != nil && *filterOpts.CheckedBaggageIncluded &&
+		!baggage.Checked.Included {
+		return false
+	}
+
+	if filterOpts.MinCheckedBaggageKg != nil && *filterOpts.MinCheckedBaggageKg > 0 &&
+		(!baggage.Checked.Included || baggage.Checked.WeightKg == nil ||
+			*baggage.Checked.WeightKg < *filterOpts.MinCheckedBaggageKg) {
+		return false
+	}
+
+	return true
+}
+
+// hasAmenities checks the flight offers every required amenity
+func hasAmenities(amenities []string, required []string) bool {
+	for _, r := range required {
+		found := false
+		for _, a := range amenities {
+			if a == r {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsAirport(airports []string, airport string) bool {
+	for _, a := range airports {
+		if strings.EqualFold(a, airport) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // startTime and endTime will be time only, without date and will depend on targetTime timezone
 // e.g. arrival at gmt+8 at 14:00, so will be checked if 14:00 is between startTime and endTime
 func isWithinTimeRange(ctx context.Context, targetTime string, startTime string, endTime string) bool {
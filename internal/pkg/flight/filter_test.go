@@ -47,8 +47,72 @@ func TestFilterFlights(t *testing.T) {
 
 	t.Run("nil_filter", filterRequest(flights, nil, []string{"1", "2"}))
 	t.Run("filter_by_airline", filterRequest(flights, &dto.FilterOption{Airline: &airlineGaruda}, []string{"1"}))
+	t.Run("filter_by_operating_airline", filterRequest([]dto.Flight{
+		{ID: "codeshare", Airline: dto.Airline{Code: "GA"}, OperatingAirline: dto.Airline{Code: "QG"}},
+		{ID: "citilink", Airline: dto.Airline{Code: "QG"}, OperatingAirline: dto.Airline{Code: "QG"}},
+		{ID: "lion", Airline: dto.Airline{Code: "JT"}, OperatingAirline: dto.Airline{Code: "JT"}},
+	}, &dto.FilterOption{Airline: func() *string { s := "QG"; return &s }()}, []string{"codeshare", "citilink"}))
 	t.Run("filter_by_max_price", filterRequest(flights, &dto.FilterOption{MaxPrice: &maxPrice}, []string{"1"}))
 	t.Run("no_match", filterRequest(flights, &dto.FilterOption{MaxPrice: func() *float64 { f := 100.0; return &f }()}, []string{}))
+
+	connectingFlights := []dto.Flight{
+		{ID: "direct"},
+		{
+			ID: "short_layover_sub",
+			Layovers: []dto.Layover{
+				{Airport: "SUB", Duration: dto.Duration{TotalMinutes: 40}},
+			},
+		},
+		{
+			ID: "overnight_upg",
+			Layovers: []dto.Layover{
+				{Airport: "UPG", Duration: dto.Duration{TotalMinutes: 600}},
+			},
+		},
+	}
+	minutes := func(m int) *int { return &m }
+
+	t.Run("filter_by_min_layover", filterRequest(connectingFlights,
+		&dto.FilterOption{MinLayoverMinutes: minutes(60)}, []string{"direct", "overnight_upg"}))
+	t.Run("filter_by_max_layover", filterRequest(connectingFlights,
+		&dto.FilterOption{MaxLayoverMinutes: minutes(240)}, []string{"direct", "short_layover_sub"}))
+	t.Run("exclude_layover_airport", filterRequest(connectingFlights,
+		&dto.FilterOption{ExcludeLayoverAirports: []string{"upg"}}, []string{"direct", "short_layover_sub"}))
+	t.Run("include_layover_airport", filterRequest(connectingFlights,
+		&dto.FilterOption{IncludeLayoverAirports: []string{"UPG"}}, []string{"overnight_upg"}))
+
+	kg := func(k int) *int { return &k }
+	baggageFlights := []dto.Flight{
+		{ID: "cabin_only", Baggage: dto.Baggage{
+			CarryOn: dto.BaggageAllowance{Included: true, WeightKg: kg(7)},
+			Checked: dto.BaggageAllowance{Description: "checked bags additional fee"},
+		}},
+		{ID: "pieces_only", Baggage: dto.Baggage{
+			Checked: dto.BaggageAllowance{Included: true, Pieces: kg(2)},
+		}},
+		{ID: "checked_20kg", Baggage: dto.Baggage{
+			Checked: dto.BaggageAllowance{Included: true, WeightKg: kg(20)},
+		}},
+	}
+	included := true
+
+	t.Run("checked_baggage_included", filterRequest(baggageFlights,
+		&dto.FilterOption{CheckedBaggageIncluded: &included}, []string{"pieces_only", "checked_20kg"}))
+	t.Run("min_checked_baggage_kg", filterRequest(baggageFlights,
+		&dto.FilterOption{MinCheckedBaggageKg: kg(15)}, []string{"checked_20kg"}))
+	t.Run("min_checked_baggage_kg_too_heavy", filterRequest(baggageFlights,
+		&dto.FilterOption{MinCheckedBaggageKg: kg(30)}, []string{}))
+
+	amenityFlights := []dto.Flight{
+		{ID: "none", Amenities: []string{}},
+		{ID: "wifi", Amenities: []string{dto.AmenityWifi}},
+		{ID: "wifi_meal", Amenities: []string{dto.AmenityMeal, dto.AmenityWifi}},
+	}
+
+	t.Run("filter_by_amenity", filterRequest(amenityFlights,
+		&dto.FilterOption{Amenities: []string{dto.AmenityWifi}}, []string{"wifi", "wifi_meal"}))
+	t.Run("filter_by_every_amenity", filterRequest(amenityFlights,
+		&dto.FilterOption{Amenities: []string{dto.AmenityWifi, dto.AmenityMeal}}, []string{"wifi_meal"}))
 }
 
 func TestIsWithinTimeRange_Closure(t *testing.T) {
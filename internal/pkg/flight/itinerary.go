@@ -0,0 +1,151 @@
+package flight
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
+)
+
+// MaxItineraries caps the combinations built from the legs, every leg multiplies
+// the number of itineraries so legs should be sorted best first before combining
+const MaxItineraries = 1000
+
+// BuildItineraries combines one flight from every leg into priced itineraries.
+// a flight can only follow the previous leg when it departs at least
+// minConnection after the previous leg arrives
+func BuildItineraries(legs [][]dto.Flight, minConnection time.Duration) []dto.Itinerary {
+	if len(legs) == 0 {
+		return []dto.Itinerary{}
+	}
+
+	itineraries := []dto.Itinerary{}
+	combineLegs(legs, minConnection, make([]dto.Flight, 0, len(legs)), &itineraries)
+
+	return itineraries
+}
+
+func combineLegs(legs [][]dto.Flight, minConnection time.Duration,
+	current []dto.Flight, itineraries *[]dto.Itinerary) {
+	if len(current) == len(legs) {
+		*itineraries = append(*itineraries, newItinerary(current))
+		return
+	}
+
+	for _, flight := range legs[len(current)] {
+		if len(*itineraries) >= MaxItineraries {
+			return
+		}
+
+		if len(current) > 0 {
+			previous := current[len(current)-1]
+			earliestDeparture := previous.Arrival.Timestamp + int64(minConnection.Seconds())
+			if flight.Departure.Timestamp < earliestDeparture {
+				continue
+			}
+		}
+
+		combineLegs(legs, minConnection, append(current, flight), itineraries)
+	}
+}
+
+func newItinerary(flights []dto.Flight) dto.Itinerary {
+	var (
+		ids          = make([]string, len(flights))
+		prices       = make([]dto.Price, len(flights))
+		totalMinutes int
+		totalStops   int
+	)
+
+	for i, flight := range flights {
+		ids[i] = flight.ID
+		prices[i] = flight.Price
+		totalMinutes += flight.Duration.TotalMinutes
+		totalStops += flight.Stops
+	}
+
+	// copy legs because the caller keeps reusing the backing array
+	legs := make([]dto.Flight, len(flights))
+	copy(legs, flights)
+
+	return dto.Itinerary{
+		ID:    strings.Join(ids, "+"),
+		Legs:  legs,
+		Price: sumPrices(prices),
+		Fares: sumFares(legs),
+		Duration: dto.Duration{
+			TotalMinutes: totalMinutes,
+			Formatted:    utils.ConvertMinutesToDuration(int64(totalMinutes)),
+		},
+		Stops: totalStops,
+	}
+}
+
+// RankItineraries ranks itineraries with the same weighted scoring as RankFlights
+// applied to the whole trip
+func RankItineraries(itineraries []dto.Itinerary) []dto.Itinerary {
+	trips := make([]dto.Flight, len(itineraries))
+	for i, itinerary := range itineraries {
+		trips[i] = toTrip(itinerary, i)
+	}
+
+	RankFlights(trips)
+
+	for i := range itineraries {
+		itineraries[i].Score = trips[i].Score
+	}
+
+	return itineraries
+}
+
+// SortItineraries sorts itineraries with the same options as SortFlights.
+// departure time is taken from the first leg and arrival time from the last leg
+func SortItineraries(itineraries []dto.Itinerary, sortOption *dto.SortOption) []dto.Itinerary {
+	trips := make([]dto.Flight, len(itineraries))
+	for i, itinerary := range itineraries {
+		trips[i] = toTrip(itinerary, i)
+	}
+
+	trips = SortFlights(trips, sortOption)
+
+	sorted := make([]dto.Itinerary, len(itineraries))
+	for i, trip := range trips {
+		index, _ := strconv.Atoi(trip.ID)
+		sorted[i] = itineraries[index]
+	}
+
+	return sorted
+}
+
+// toTrip summarizes an itinerary as a single flight so the flight ranking
+// and sorting can be reused, the ID is the itinerary index
+func toTrip(itinerary dto.Itinerary, index int) dto.Flight {
+	trip := dto.Flight{
+		ID:       strconv.Itoa(index),
+		Duration: itinerary.Duration,
+		Stops:    itinerary.Stops,
+		Price:    itinerary.Price,
+		Score:    itinerary.Score,
+	}
+
+	if len(itinerary.Legs) == 0 {
+		return trip
+	}
+
+	trip.Departure = itinerary.Legs[0].Departure
+	trip.Arrival = itinerary.Legs[len(itinerary.Legs)-1].Arrival
+
+	seen := map[string]bool{}
+	for _, leg := range itinerary.Legs {
+		for _, amenity := range leg.Amenities {
+			if !seen[amenity] {
+				seen[amenity] = true
+				trip.Amenities = append(trip.Amenities, amenity)
+			}
+		}
+	}
+
+	return trip
+}
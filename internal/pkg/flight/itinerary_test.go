@@ -0,0 +1,102 @@
+//go:build unit
+
+package flight
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestBuildItineraries_Closure(t *testing.T) {
+	outbound := []dto.Flight{
+		{
+			ID:        "OUT1",
+			Departure: dto.Departure{Timestamp: 1000},
+			Arrival:   dto.Arrival{Timestamp: 2000},
+			Price:     dto.Price{Amount: 500000, Currency: "IDR"},
+			Duration:  dto.Duration{TotalMinutes: 100},
+		},
+		{
+			ID:        "OUT2",
+			Departure: dto.Departure{Timestamp: 5000},
+			Arrival:   dto.Arrival{Timestamp: 9000},
+			Price:     dto.Price{Amount: 300000, Currency: "IDR"},
+			Duration:  dto.Duration{TotalMinutes: 200},
+			Stops:     1,
+		},
+	}
+	inbound := []dto.Flight{
+		{
+			ID:        "IN1",
+			Departure: dto.Departure{Timestamp: 8000},
+			Arrival:   dto.Arrival{Timestamp: 9500},
+			Price:     dto.Price{Amount: 400000, Currency: "IDR"},
+			Duration:  dto.Duration{TotalMinutes: 90},
+		},
+	}
+
+	buildRequest := func(legs [][]dto.Flight, minConnection time.Duration, wantIDs []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := BuildItineraries(legs, minConnection)
+			gotIDs := make([]string, len(got))
+			for i, itinerary := range got {
+				gotIDs[i] = itinerary.ID
+			}
+
+			diff := cmp.Diff(wantIDs, gotIDs)
+			if diff != "" {
+				t.Fatalf("BuildItineraries result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("skip_inbound_departing_before_outbound_arrival",
+		buildRequest([][]dto.Flight{outbound, inbound}, 0, []string{"OUT1+IN1"}))
+	t.Run("enforce_min_connection",
+		buildRequest([][]dto.Flight{outbound, inbound}, 2*time.Hour, []string{}))
+	t.Run("empty_leg", buildRequest([][]dto.Flight{outbound, {}}, 0, []string{}))
+
+	t.Run("total_price_duration_and_stops", func(t *testing.T) {
+		got := BuildItineraries([][]dto.Flight{outbound[:1], inbound}, 0)
+		want := dto.Itinerary{
+			ID:       "OUT1+IN1",
+			Legs:     []dto.Flight{outbound[0], inbound[0]},
+			Price:    dto.Price{Amount: 900000, Currency: "IDR", Formatted: "Rp900.000"},
+			Duration: dto.Duration{TotalMinutes: 190, Formatted: "3h 10m"},
+		}
+
+		diff := cmp.Diff([]dto.Itinerary{want}, got)
+		if diff != "" {
+			t.Fatalf("BuildItineraries mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestSortItineraries_Closure(t *testing.T) {
+	itineraries := []dto.Itinerary{
+		{ID: "A", Price: dto.Price{Amount: 2000}, Score: 0.9},
+		{ID: "B", Price: dto.Price{Amount: 1000}, Score: 0.2},
+		{ID: "C", Price: dto.Price{Amount: 1500}, Score: 0.1},
+	}
+
+	sortRequest := func(opt *dto.SortOption, wantIDs []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := SortItineraries(itineraries, opt)
+			gotIDs := make([]string, len(got))
+			for i, itinerary := range got {
+				gotIDs[i] = itinerary.ID
+			}
+
+			diff := cmp.Diff(wantIDs, gotIDs)
+			if diff != "" {
+				t.Fatalf("SortItineraries result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("default_sort_best_score_asc", sortRequest(nil, []string{"C", "B", "A"}))
+	t.Run("price_desc", sortRequest(&dto.SortOption{Field: "price", Order: "desc"}, []string{"A", "C", "B"}))
+}
@@ -0,0 +1,91 @@
+package flight
+
+import (
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
+)
+
+// LocalizeFlights formats every price and duration of the flights,
+// formatting is recomputed from the amounts so it can be applied more than once
+func LocalizeFlights(flights []dto.Flight, l locale.Locale) {
+	for i := range flights {
+		localizeFlight(&flights[i], l)
+	}
+}
+
+// LocalizeItineraries formats every price and duration of the itineraries and their legs
+func LocalizeItineraries(itineraries []dto.Itinerary, l locale.Locale) {
+	for i := range itineraries {
+		localizePrice(&itineraries[i].Price, l)
+		localizeFares(itineraries[i].Fares, l)
+		localizeDuration(&itineraries[i].Duration, l)
+		LocalizeFlights(itineraries[i].Legs, l)
+	}
+}
+
+// LocalizeCalendar formats the lowest price and fastest duration of every day
+func LocalizeCalendar(calendar []dto.CalendarDay, l locale.Locale) {
+	for i := range calendar {
+		if calendar[i].LowestPrice != nil {
+			localizePrice(calendar[i].LowestPrice, l)
+		}
+		if calendar[i].FastestDuration != nil {
+			localizeDuration(calendar[i].FastestDuration, l)
+		}
+	}
+}
+
+// LocalizeFacets formats the lowest price of every airline and the duration range
+func LocalizeFacets(facets *dto.Facets, l locale.Locale) {
+	if facets == nil {
+		return
+	}
+
+	for i := range facets.Airlines {
+		localizePrice(&facets.Airlines[i].MinPrice, l)
+	}
+	if facets.Duration != nil {
+		localizeDuration(&facets.Duration.Min, l)
+		localizeDuration(&facets.Duration.Max, l)
+	}
+}
+
+func localizeFlight(f *dto.Flight, l locale.Locale) {
+	localizePrice(&f.Price, l)
+	localizeFares(f.Fares, l)
+	localizeDuration(&f.Duration, l)
+
+	for i := range f.Offers {
+		localizePrice(&f.Offers[i].Price, l)
+	}
+
+	for i := range f.Segments {
+		if f.Segments[i].Duration != nil {
+			localizeDuration(f.Segments[i].Duration, l)
+		}
+	}
+
+	for i := range f.Layovers {
+		localizeDuration(&f.Layovers[i].Duration, l)
+	}
+}
+
+func localizeFares(fares *dto.Fares, l locale.Locale) {
+	if fares == nil {
+		return
+	}
+
+	for i := range fares.Passengers {
+		localizePrice(&fares.Passengers[i].UnitPrice, l)
+		localizePrice(&fares.Passengers[i].Total, l)
+	}
+	localizePrice(&fares.Total, l)
+}
+
+func localizePrice(price *dto.Price, l locale.Locale) {
+	price.Formatted = l.FormatPrice(price.Amount, price.Currency)
+}
+
+func localizeDuration(duration *dto.Duration, l locale.Locale) {
+	duration.Formatted = l.FormatDuration(duration.TotalMinutes)
+}
@@ -0,0 +1,52 @@
+//go:build unit
+
+package flight
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/locale"
+)
+
+func TestLocalizeItineraries(t *testing.T) {
+	l, _ := locale.Lookup(locale.Indonesian)
+
+	leg := dto.Flight{
+		ID:       "GA-1",
+		Price:    dto.Price{Amount: 1500000, Currency: "IDR", Formatted: "Rp1.500.000"},
+		Duration: dto.Duration{TotalMinutes: 185, Formatted: "3h 5m"},
+		Layovers: []dto.Layover{{Airport: "SUB", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}}},
+		Offers: []dto.Offer{
+			{ID: "GA-1", Price: dto.Price{Amount: 1500000, Currency: "IDR", Formatted: "Rp1.500.000"}},
+		},
+	}
+	itineraries := []dto.Itinerary{
+		{
+			ID:       "GA-1",
+			Legs:     []dto.Flight{leg},
+			Price:    dto.Price{Amount: 150.5, Currency: "SGD", Formatted: "S$150.50"},
+			Duration: dto.Duration{TotalMinutes: 185, Formatted: "3h 5m"},
+		},
+	}
+
+	LocalizeItineraries(itineraries, l)
+
+	wantLeg := leg
+	wantLeg.Price.Formatted = "Rp1.500.000"
+	wantLeg.Duration.Formatted = "3 jam 5 menit"
+	wantLeg.Layovers = []dto.Layover{{Airport: "SUB", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1 jam"}}}
+	want := []dto.Itinerary{
+		{
+			ID:       "GA-1",
+			Legs:     []dto.Flight{wantLeg},
+			Price:    dto.Price{Amount: 150.5, Currency: "SGD", Formatted: "S$150,50"},
+			Duration: dto.Duration{TotalMinutes: 185, Formatted: "3 jam 5 menit"},
+		},
+	}
+
+	if diff := cmp.Diff(want, itineraries); diff != "" {
+		t.Fatalf("LocalizeItineraries result mismatch (-want +got):\n%s", diff)
+	}
+}
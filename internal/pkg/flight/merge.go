@@ -0,0 +1,76 @@
+package flight
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// MergeDuplicates collapses the same physical flight sold by different providers into one flight.
+// flights are the same when operating carrier, flight number, departure time and cabin match,
+// the fares of other cabins of the same flight stay separate flights.
+// the merged flight keeps the cheapest offer and lists every offer
+func MergeDuplicates(flights []dto.Flight) []dto.Flight {
+	results := make([]dto.Flight, 0, len(flights))
+	index := make(map[string]int, len(flights))
+
+	for _, f := range flights {
+		key := physicalFlightKey(f)
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(results)
+			results = append(results, f)
+			continue
+		}
+
+		merged := results[i]
+		if len(merged.Offers) == 0 {
+			merged.Offers = []dto.Offer{newOffer(merged)}
+		}
+		merged.Offers = append(merged.Offers, newOffer(f))
+
+		// the cheapest offer is shown as the flight
+		if f.Price.Amount < merged.Price.Amount {
+			offers := merged.Offers
+			merged = f
+			merged.Offers = offers
+		}
+
+		results[i] = merged
+	}
+
+	for i := range results {
+		markCheapestOffer(results[i])
+	}
+
+	return results
+}
+
+func physicalFlightKey(f dto.Flight) string {
+	flightNumber := strings.ToUpper(strings.ReplaceAll(f.FlightNumber, " ", ""))
+
+	carrier := f.OperatingAirline.Code
+	if carrier == "" {
+		carrier = f.Airline.Code
+	}
+
+	// the cabin is normalized by the providers
+	return fmt.Sprintf("%s:%s:%d:%s:%s",
+		strings.ToUpper(carrier), flightNumber, f.Departure.Timestamp, f.Direction, f.CabinClass)
+}
+
+func newOffer(f dto.Flight) dto.Offer {
+	return dto.Offer{
+		ID:             f.ID,
+		Provider:       f.Provider,
+		Price:          f.Price,
+		AvailableSeats: f.AvailableSeats,
+	}
+}
+
+func markCheapestOffer(f dto.Flight) {
+	for i := range f.Offers {
+		f.Offers[i].Cheapest = f.Offers[i].ID == f.ID
+	}
+}
@@ -0,0 +1,89 @@
+//go:build unit
+
+package flight
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestMergeDuplicates_Closure(t *testing.T) {
+	mergeRequest := func(flights []dto.Flight, want []dto.Flight) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := MergeDuplicates(flights)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("MergeDuplicates result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	resold := dto.Flight{
+		ID:               "JT650_LionAir",
+		Provider:         "LionAir",
+		Airline:          dto.Airline{Code: "JT"},
+		OperatingAirline: dto.Airline{Code: "JT"},
+		FlightNumber:     "JT650",
+		Departure:        dto.Departure{Timestamp: 1000},
+		CabinClass:       dto.CabinEconomy,
+		Price:            dto.Price{Amount: 800000},
+		AvailableSeats:   10,
+	}
+	cheaper := resold
+	cheaper.ID = "JT 650_Reseller"
+	cheaper.Provider = "Reseller"
+	// providers report their own airline, the operating carrier comes from the payload
+	cheaper.Airline = dto.Airline{Code: "RS"}
+	cheaper.FlightNumber = "JT 650"
+	cheaper.Price = dto.Price{Amount: 750000}
+	cheaper.AvailableSeats = 4
+
+	laterDeparture := resold
+	laterDeparture.ID = "JT650_Later"
+	laterDeparture.Departure = dto.Departure{Timestamp: 2000}
+
+	mergedCheaper := cheaper
+	mergedCheaper.Offers = []dto.Offer{
+		{ID: "JT650_LionAir", Provider: "LionAir", Price: dto.Price{Amount: 800000}, AvailableSeats: 10},
+		{ID: "JT 650_Reseller", Provider: "Reseller", Price: dto.Price{Amount: 750000}, AvailableSeats: 4, Cheapest: true},
+	}
+
+	t.Run("merge_same_flight_keep_cheapest", mergeRequest(
+		[]dto.Flight{resold, cheaper},
+		[]dto.Flight{mergedCheaper},
+	))
+
+	// every provider sells the economy and the business fare of the same flight
+	business := resold
+	business.ID = "JT650_LionAir_Business"
+	business.CabinClass = dto.CabinBusiness
+	business.Price = dto.Price{Amount: 3000000}
+	business.AvailableSeats = 2
+
+	cheaperBusiness := cheaper
+	cheaperBusiness.ID = "JT 650_Reseller_Business"
+	cheaperBusiness.CabinClass = dto.CabinBusiness
+	cheaperBusiness.Price = dto.Price{Amount: 2800000}
+	cheaperBusiness.AvailableSeats = 1
+
+	mergedBusiness := cheaperBusiness
+	mergedBusiness.Offers = []dto.Offer{
+		{ID: "JT650_LionAir_Business", Provider: "LionAir", Price: dto.Price{Amount: 3000000}, AvailableSeats: 2},
+		{
+			ID: "JT 650_Reseller_Business", Provider: "Reseller", Price: dto.Price{Amount: 2800000},
+			AvailableSeats: 1, Cheapest: true,
+		},
+	}
+
+	t.Run("merge_every_cabin_apart", mergeRequest(
+		[]dto.Flight{resold, business, cheaper, cheaperBusiness},
+		[]dto.Flight{mergedCheaper, mergedBusiness},
+	))
+	t.Run("different_departure_not_merged", mergeRequest(
+		[]dto.Flight{resold, laterDeparture},
+		[]dto.Flight{resold, laterDeparture},
+	))
+}
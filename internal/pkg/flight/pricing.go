@@ -0,0 +1,171 @@
+package flight
+
+import (
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+)
+
+// FareRatios price children and infants from the adult fare. the providers only price adults
+// so the fares of children and infants are derived and marked so in the response
+type FareRatios struct {
+	Child  float64
+	Infant float64
+}
+
+// DefaultFareRatios are the usual domestic ratios, used when the ratios aren't configured
+var DefaultFareRatios = FareRatios{Child: 0.75, Infant: 0.10}
+
+// ApplyFares prices every passenger of the search on each flight,
+// the flight price is left as the fare of one adult
+func ApplyFares(flights []dto.Flight, passengers dto.PassengerTypes, ratios FareRatios) {
+	for i := range flights {
+		flights[i].Fares = newFares(flights[i].Price, passengers, ratios)
+	}
+}
+
+func newFares(adultPrice dto.Price, passengers dto.PassengerTypes, ratios FareRatios) *dto.Fares {
+	types := []struct {
+		name  string
+		count int
+		ratio float64
+	}{
+		{dto.PassengerTypeAdult, passengers.Adults, 1},
+		{dto.PassengerTypeChild, passengers.Children, ratios.Child},
+		{dto.PassengerTypeInfant, passengers.Infants, ratios.Infant},
+	}
+
+	fares := &dto.Fares{Passengers: []dto.PassengerFare{}}
+	totals := []dto.Price{}
+
+	for _, t := range types {
+		if t.count == 0 {
+			continue
+		}
+
+		// only the adult fare is priced by the provider
+		derived := t.name != dto.PassengerTypeAdult
+
+		unit := scalePrice(adultPrice, t.ratio)
+		if derived && unit.Breakdown != nil {
+			unit.Breakdown.Reported = false
+		}
+
+		fare := dto.PassengerFare{
+			Type:      t.name,
+			Count:     t.count,
+			UnitPrice: unit,
+			Total:     scalePrice(unit, float64(t.count)),
+			Derived:   derived,
+		}
+		fares.Passengers = append(fares.Passengers, fare)
+		totals = append(totals, fare.Total)
+	}
+
+	fares.Total = sumPrices(totals)
+
+	return fares
+}
+
+// sumFares adds up the fares of the legs of an itinerary per passenger type
+func sumFares(flights []dto.Flight) *dto.Fares {
+	if len(flights) == 0 || flights[0].Fares == nil {
+		return nil
+	}
+
+	fares := &dto.Fares{Passengers: []dto.PassengerFare{}}
+	totals := []dto.Price{}
+
+	for i, passenger := range flights[0].Fares.Passengers {
+		units := make([]dto.Price, len(flights))
+		derived := false
+		for j, flight := range flights {
+			if flight.Fares == nil || i >= len(flight.Fares.Passengers) {
+				return nil
+			}
+			units[j] = flight.Fares.Passengers[i].UnitPrice
+			derived = derived || flight.Fares.Passengers[i].Derived
+		}
+
+		unit := sumPrices(units)
+		fare := dto.PassengerFare{
+			Type:      passenger.Type,
+			Count:     passenger.Count,
+			UnitPrice: unit,
+			Total:     scalePrice(unit, float64(passenger.Count)),
+			Derived:   derived,
+		}
+		fares.Passengers = append(fares.Passengers, fare)
+		totals = append(totals, fare.Total)
+	}
+
+	fares.Total = sumPrices(totals)
+
+	return fares
+}
+
+// sumPrices adds up prices of the same currency, the breakdown is only kept
+// when every price has one and is only reported when every price is
+func sumPrices(prices []dto.Price) dto.Price {
+	if len(prices) == 0 {
+		return dto.Price{}
+	}
+
+	sum := dto.Price{Currency: prices[0].Currency}
+	if prices[0].Breakdown != nil {
+		sum.Breakdown = &dto.FareBreakdown{Reported: true}
+	}
+
+	for _, price := range prices {
+		sum.Amount += price.Amount
+
+		if sum.Breakdown == nil || price.Breakdown == nil {
+			sum.Breakdown = nil
+			continue
+		}
+
+		sum.Breakdown.BaseFare += price.Breakdown.BaseFare
+		sum.Breakdown.Taxes += price.Breakdown.Taxes
+		sum.Breakdown.Surcharges += price.Breakdown.Surcharges
+		sum.Breakdown.ServiceFees += price.Breakdown.ServiceFees
+		sum.Breakdown.Reported = sum.Breakdown.Reported && price.Breakdown.Reported
+	}
+
+	sum.Formatted = currency.Format(sum.Amount, sum.Currency)
+
+	return sum
+}
+
+// scalePrice multiplies the price and every component of its breakdown, each
+// rounded to the minor unit of the currency so a derived unit price is a price
+// that can be charged and its total is exactly the count times the unit price
+func scalePrice(price dto.Price, factor float64) dto.Price {
+	scaled := dto.Price{
+		Amount:   currency.Round(price.Amount*factor, price.Currency),
+		Currency: price.Currency,
+	}
+	scaled.Formatted = currency.Format(scaled.Amount, scaled.Currency)
+
+	if price.Breakdown != nil {
+		scaled.Breakdown = &dto.FareBreakdown{
+			BaseFare:    currency.Round(price.Breakdown.BaseFare*factor, price.Currency),
+			Taxes:       currency.Round(price.Breakdown.Taxes*factor, price.Currency),
+			Surcharges:  currency.Round(price.Breakdown.Surcharges*factor, price.Currency),
+			ServiceFees: currency.Round(price.Breakdown.ServiceFees*factor, price.Currency),
+			Reported:    price.Breakdown.Reported,
+		}
+
+		// the rounding difference goes to the base fare so a breakdown adding
+		// up to the price still does
+		if currency.Round(breakdownSum(price.Breakdown), price.Currency) ==
+			currency.Round(price.Amount, price.Currency) {
+			scaled.Breakdown.BaseFare += scaled.Amount - breakdownSum(scaled.Breakdown)
+			scaled.Breakdown.BaseFare = currency.Round(scaled.Breakdown.BaseFare, price.Currency)
+		}
+	}
+
+	return scaled
+}
+
+func breakdownSum(breakdown *dto.FareBreakdown) float64 {
+	return breakdown.BaseFare + breakdown.Taxes + breakdown.Surcharges + breakdown.ServiceFees
+}
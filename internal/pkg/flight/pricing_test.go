@@ -0,0 +1,276 @@
+//go:build unit
+
+package flight
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestApplyFares_Closure(t *testing.T) {
+	applyRequest := func(passengers dto.PassengerTypes, ratios FareRatios, want *dto.Fares) func(t *testing.T) {
+		return func(t *testing.T) {
+			flights := []dto.Flight{
+				{ID: "GA-1", Price: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"}},
+			}
+
+			ApplyFares(flights, passengers, ratios)
+
+			diff := cmp.Diff(want, flights[0].Fares)
+			if diff != "" {
+				t.Fatalf("ApplyFares result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("adults_only", applyRequest(
+		dto.PassengerTypes{Adults: 2},
+		DefaultFareRatios,
+		&dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{
+					Type:      dto.PassengerTypeAdult,
+					Count:     2,
+					UnitPrice: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"},
+					Total:     dto.Price{Amount: 2000000, Currency: "IDR", Formatted: "Rp2.000.000"},
+				},
+			},
+			Total: dto.Price{Amount: 2000000, Currency: "IDR", Formatted: "Rp2.000.000"},
+		},
+	))
+
+	t.Run("adult_child_and_infant", applyRequest(
+		dto.PassengerTypes{Adults: 1, Children: 2, Infants: 1},
+		DefaultFareRatios,
+		&dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{
+					Type:      dto.PassengerTypeAdult,
+					Count:     1,
+					UnitPrice: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"},
+					Total:     dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"},
+				},
+				{
+					Type:      dto.PassengerTypeChild,
+					Count:     2,
+					UnitPrice: dto.Price{Amount: 750000, Currency: "IDR", Formatted: "Rp750.000"},
+					Total:     dto.Price{Amount: 1500000, Currency: "IDR", Formatted: "Rp1.500.000"},
+					Derived:   true,
+				},
+				{
+					Type:      dto.PassengerTypeInfant,
+					Count:     1,
+					UnitPrice: dto.Price{Amount: 100000, Currency: "IDR", Formatted: "Rp100.000"},
+					Total:     dto.Price{Amount: 100000, Currency: "IDR", Formatted: "Rp100.000"},
+					Derived:   true,
+				},
+			},
+			Total: dto.Price{Amount: 2600000, Currency: "IDR", Formatted: "Rp2.600.000"},
+		},
+	))
+
+	t.Run("configured_ratios", applyRequest(
+		dto.PassengerTypes{Adults: 1, Children: 1},
+		FareRatios{Child: 0.5, Infant: 0},
+		&dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{
+					Type:      dto.PassengerTypeAdult,
+					Count:     1,
+					UnitPrice: dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"},
+					Total:     dto.Price{Amount: 1000000, Currency: "IDR", Formatted: "Rp1.000.000"},
+				},
+				{
+					Type:      dto.PassengerTypeChild,
+					Count:     1,
+					UnitPrice: dto.Price{Amount: 500000, Currency: "IDR", Formatted: "Rp500.000"},
+					Total:     dto.Price{Amount: 500000, Currency: "IDR", Formatted: "Rp500.000"},
+					Derived:   true,
+				},
+			},
+			Total: dto.Price{Amount: 1500000, Currency: "IDR", Formatted: "Rp1.500.000"},
+		},
+	))
+}
+
+func TestApplyFares_Breakdown(t *testing.T) {
+	flights := []dto.Flight{
+		{
+			ID: "ID-1",
+			Price: dto.Price{
+				Amount: 1100000, Currency: "IDR", Formatted: "Rp1.100.000",
+				Breakdown: &dto.FareBreakdown{BaseFare: 980000, Taxes: 120000, Reported: true},
+			},
+		},
+	}
+
+	ApplyFares(flights, dto.PassengerTypes{Adults: 1, Children: 2}, DefaultFareRatios)
+
+	// child fares are estimated so their breakdown is no longer reported
+	want := &dto.Fares{
+		Passengers: []dto.PassengerFare{
+			{
+				Type:  dto.PassengerTypeAdult,
+				Count: 1,
+				UnitPrice: dto.Price{
+					Amount: 1100000, Currency: "IDR", Formatted: "Rp1.100.000",
+					Breakdown: &dto.FareBreakdown{BaseFare: 980000, Taxes: 120000, Reported: true},
+				},
+				Total: dto.Price{
+					Amount: 1100000, Currency: "IDR", Formatted: "Rp1.100.000",
+					Breakdown: &dto.FareBreakdown{BaseFare: 980000, Taxes: 120000, Reported: true},
+				},
+			},
+			{
+				Type:  dto.PassengerTypeChild,
+				Count: 2,
+				UnitPrice: dto.Price{
+					Amount: 825000, Currency: "IDR", Formatted: "Rp825.000",
+					Breakdown: &dto.FareBreakdown{BaseFare: 735000, Taxes: 90000},
+				},
+				Total: dto.Price{
+					Amount: 1650000, Currency: "IDR", Formatted: "Rp1.650.000",
+					Breakdown: &dto.FareBreakdown{BaseFare: 1470000, Taxes: 180000},
+				},
+				Derived: true,
+			},
+		},
+		Total: dto.Price{
+			Amount: 2750000, Currency: "IDR", Formatted: "Rp2.750.000",
+			Breakdown: &dto.FareBreakdown{BaseFare: 2450000, Taxes: 300000},
+		},
+	}
+
+	if diff := cmp.Diff(want, flights[0].Fares); diff != "" {
+		t.Fatalf("ApplyFares result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSumPrices_Closure(t *testing.T) {
+	sumRequest := func(prices []dto.Price, want dto.Price) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := sumPrices(prices)
+
+			diff := cmp.Diff(want, got)
+			if diff != "" {
+				t.Fatalf("sumPrices result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("reported_and_derived_breakdowns", sumRequest(
+		[]dto.Price{
+			{Amount: 1100000, Currency: "IDR", Breakdown: &dto.FareBreakdown{
+				BaseFare: 980000, Taxes: 100000, Surcharges: 20000, Reported: true,
+			}},
+			{Amount: 555000, Currency: "IDR", Breakdown: &dto.FareBreakdown{BaseFare: 500000, Taxes: 55000}},
+		},
+		dto.Price{
+			Amount: 1655000, Currency: "IDR", Formatted: "Rp1.655.000",
+			Breakdown: &dto.FareBreakdown{BaseFare: 1480000, Taxes: 155000, Surcharges: 20000},
+		},
+	))
+
+	t.Run("missing_breakdown", sumRequest(
+		[]dto.Price{
+			{Amount: 1100000, Currency: "IDR", Breakdown: &dto.FareBreakdown{BaseFare: 1100000, Reported: true}},
+			{Amount: 500000, Currency: "IDR"},
+		},
+		dto.Price{Amount: 1600000, Currency: "IDR", Formatted: "Rp1.600.000"},
+	))
+}
+
+func TestApplyFares_Rounding_Closure(t *testing.T) {
+	applyRequest := func(price dto.Price, passengers dto.PassengerTypes, want *dto.Fares) func(t *testing.T) {
+		return func(t *testing.T) {
+			flights := []dto.Flight{{ID: "ID-1", Price: price}}
+
+			ApplyFares(flights, passengers, DefaultFareRatios)
+
+			if diff := cmp.Diff(want, flights[0].Fares); diff != "" {
+				t.Fatalf("ApplyFares result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	// derived unit prices are whole rupiah and the totals are the count times them,
+	// the breakdown still adds up to the price
+	t.Run("odd_rupiah_fare", applyRequest(
+		dto.Price{
+			Amount: 1234567, Currency: "IDR", Formatted: "Rp1.234.567",
+			Breakdown: &dto.FareBreakdown{BaseFare: 1100001, Taxes: 134566, Reported: true},
+		},
+		dto.PassengerTypes{Adults: 1, Children: 2, Infants: 1},
+		&dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{
+					Type:  dto.PassengerTypeAdult,
+					Count: 1,
+					UnitPrice: dto.Price{
+						Amount: 1234567, Currency: "IDR", Formatted: "Rp1.234.567",
+						Breakdown: &dto.FareBreakdown{BaseFare: 1100001, Taxes: 134566, Reported: true},
+					},
+					Total: dto.Price{
+						Amount: 1234567, Currency: "IDR", Formatted: "Rp1.234.567",
+						Breakdown: &dto.FareBreakdown{BaseFare: 1100001, Taxes: 134566, Reported: true},
+					},
+				},
+				{
+					Type:  dto.PassengerTypeChild,
+					Count: 2,
+					UnitPrice: dto.Price{
+						Amount: 925925, Currency: "IDR", Formatted: "Rp925.925",
+						Breakdown: &dto.FareBreakdown{BaseFare: 825000, Taxes: 100925},
+					},
+					Total: dto.Price{
+						Amount: 1851850, Currency: "IDR", Formatted: "Rp1.851.850",
+						Breakdown: &dto.FareBreakdown{BaseFare: 1650000, Taxes: 201850},
+					},
+					Derived: true,
+				},
+				{
+					Type:  dto.PassengerTypeInfant,
+					Count: 1,
+					UnitPrice: dto.Price{
+						Amount: 123457, Currency: "IDR", Formatted: "Rp123.457",
+						Breakdown: &dto.FareBreakdown{BaseFare: 110000, Taxes: 13457},
+					},
+					Total: dto.Price{
+						Amount: 123457, Currency: "IDR", Formatted: "Rp123.457",
+						Breakdown: &dto.FareBreakdown{BaseFare: 110000, Taxes: 13457},
+					},
+					Derived: true,
+				},
+			},
+			Total: dto.Price{
+				Amount: 3209874, Currency: "IDR", Formatted: "Rp3.209.874",
+				Breakdown: &dto.FareBreakdown{BaseFare: 2860001, Taxes: 349873},
+			},
+		},
+	))
+
+	t.Run("odd_cent_fare", applyRequest(
+		dto.Price{Amount: 100.01, Currency: "USD", Formatted: "US$100.01"},
+		dto.PassengerTypes{Adults: 1, Children: 3},
+		&dto.Fares{
+			Passengers: []dto.PassengerFare{
+				{
+					Type:      dto.PassengerTypeAdult,
+					Count:     1,
+					UnitPrice: dto.Price{Amount: 100.01, Currency: "USD", Formatted: "US$100.01"},
+					Total:     dto.Price{Amount: 100.01, Currency: "USD", Formatted: "US$100.01"},
+				},
+				{
+					Type:      dto.PassengerTypeChild,
+					Count:     3,
+					UnitPrice: dto.Price{Amount: 75.01, Currency: "USD", Formatted: "US$75.01"},
+					Total:     dto.Price{Amount: 225.03, Currency: "USD", Formatted: "US$225.03"},
+					Derived:   true,
+				},
+			},
+			Total: dto.Price{Amount: 325.04, Currency: "USD", Formatted: "US$325.04"},
+		},
+	))
+}
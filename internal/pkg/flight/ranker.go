@@ -17,6 +17,19 @@ const (
 	WeightAmenities         = 0.05
 )
 
+// AmenityWeights is the value of each canonical amenity in the amenities score,
+// amenities missing from the map count for nothing
+var AmenityWeights = map[string]float64{
+	dto.AmenityWifi:          0.25,
+	dto.AmenityMeal:          0.2,
+	dto.AmenityEntertainment: 0.15,
+	dto.AmenityPower:         0.1,
+	dto.AmenityExtraLegroom:  0.1,
+	dto.AmenitySnack:         0.08,
+	dto.AmenityBeverage:      0.07,
+	dto.AmenityUSB:           0.05,
+}
+
 // RankFlights ranks the flights based on the given criteria
 // score is calculated using weighted scoring using normalization
 // 0 indicates the best flight and 1 indicates the worst flight
@@ -33,9 +46,9 @@ func RankFlights(flights []dto.Flight) []dto.Flight {
 		stopsScore := normalizeValue(float64(flight.Stops),
 			float64(stopsMin), float64(stopsMax))
 
-		// invert amenities score because more amenities is better
-		amenitiesScore := 1 - normalizeValue(float64(len(flight.Amenities)),
-			float64(amenitiesMin), float64(amenitiesMax))
+		// invert amenities score because more valuable amenities is better
+		amenitiesScore := 1 - normalizeValue(amenitiesValue(flight.Amenities),
+			amenitiesMin, amenitiesMax)
 
 		flights[i].Score = WeightPrice*priceScore +
 			WeightDurationInMinutes*durationScore +
@@ -100,24 +113,34 @@ func findStopsRange(flights []dto.Flight) (int, int) {
 	return minStops, maxStops
 }
 
-func findAmenitiesRange(flights []dto.Flight) (int, int) {
+func findAmenitiesRange(flights []dto.Flight) (float64, float64) {
 	if len(flights) == 0 {
 		return 0, 0
 	}
 
-	minAmenities := math.MaxInt
-	maxAmenities := -math.MaxInt
+	minAmenities := math.MaxFloat64
+	maxAmenities := -math.MaxFloat64
 	for _, flight := range flights {
-		if len(flight.Amenities) < minAmenities {
-			minAmenities = len(flight.Amenities)
+		value := amenitiesValue(flight.Amenities)
+		if value < minAmenities {
+			minAmenities = value
 		}
-		if len(flight.Amenities) > maxAmenities {
-			maxAmenities = len(flight.Amenities)
+		if value > maxAmenities {
+			maxAmenities = value
 		}
 	}
 	return minAmenities, maxAmenities
 }
 
+// amenitiesValue sums the weights of the amenities
+func amenitiesValue(amenities []string) float64 {
+	value := 0.0
+	for _, amenity := range amenities {
+		value += AmenityWeights[amenity]
+	}
+	return value
+}
+
 func normalizeValue(value float64, min float64, max float64) float64 {
 	if max == min {
 		return 0
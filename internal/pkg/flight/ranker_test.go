@@ -16,7 +16,7 @@ func TestRankFlights_Closure(t *testing.T) {
 			Price:     dto.Price{Amount: 1000},
 			Duration:  dto.Duration{TotalMinutes: 100},
 			Stops:     0,
-			Amenities: []string{"WiFi"},
+			Amenities: []string{dto.AmenityWifi},
 		},
 		{
 			ID:        "2",
@@ -52,6 +52,12 @@ func TestRankFlights_Closure(t *testing.T) {
 	}
 
 	t.Run("basic_ranking", rankRequest(flights, "1"))
+
+	// same price, duration and stops so only the amenity weights decide
+	t.Run("amenity_weights_over_count", rankRequest([]dto.Flight{
+		{ID: "snack_beverage_usb", Amenities: []string{dto.AmenitySnack, dto.AmenityBeverage, dto.AmenityUSB}},
+		{ID: "wifi", Amenities: []string{dto.AmenityWifi}},
+	}, "wifi"))
 }
 
 func TestNormalizeValue_Closure(t *testing.T) {
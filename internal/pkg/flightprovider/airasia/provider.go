@@ -4,14 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
-	"math/rand"
-	"os"
-	"strings"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
@@ -24,91 +24,87 @@ const (
 
 type Provider struct {
 	Name         string
-	SearchAPIURL string
+	Transport    providerutils.Transport
 	Timeout      time.Duration
-	MaxRetries   int
+	Retry        *flightprovider.RetryPolicy
 	Limiter      *redis_rate.Limiter
 	RateLimitRPS int
 }
 
-func NewProvider(config flightprovider.FlightProviderConfig) *Provider {
+func NewProvider(config flightprovider.FlightProviderConfig) (*Provider, error) {
+	transport, err := providerutils.NewTransport(providerutils.TransportConfig{
+		Mode:             config.Mode,
+		URL:              config.SearchAPIURL,
+		Auth:             providerutils.APIKeyAuth("apikey", config.APIKey),
+		Client:           config.HTTPClient,
+		MaxResponseBytes: config.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %w", ProviderName, err)
+	}
+
 	return &Provider{
 		Name:         ProviderName,
-		SearchAPIURL: config.SearchAPIURL,
+		Transport:    transport,
 		Timeout:      config.Timeout,
-		MaxRetries:   config.MaxRetries,
+		Retry:        flightprovider.NewRetryPolicy(ProviderName, config),
 		Limiter:      config.Limiter,
 		RateLimitRPS: config.RateLimitRPS,
-	}
+	}, nil
 }
 
-// Search will simulate API call to AirAsia flight search API
-// it will return 90% success with delay 50-150ms
-// and will retry if failed
+// Search calls the AirAsia flight search API, or reads the fixture file in file mode,
+// failed calls are retried with the retry policy of the provider
 func (p *Provider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
 	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
 	defer cancel()
 
-	var lastErr error
-	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
-		// simulate delay 50-150ms
-		delay := time.Duration(50+rand.Intn(101)) * time.Millisecond
-		select {
-		case <-time.After(delay):
-		case <-ctx.Done():
-			return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
-		}
-
-		// simulate call 90% success, 10% failure
-		if rand.Float64() < 0.1 {
-			lastErr = providerutils.ErrProviderInternalError
-			slog.ErrorContext(ctx, "failed to call airasia flight search API", "attempt",
-				attempt+1, "error", lastErr)
-
-			if attempt < p.MaxRetries {
-				// Exponential backoff: 200ms * 2^attempt
-				backoff := time.Duration(200*(1<<attempt)) * time.Millisecond
-				slog.InfoContext(ctx, "retrying with exponential backoff", "backoff",
-					backoff, "next_attempt", attempt+2)
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				}
-			}
-			continue
-		}
-
-		// distributed rate limit using leaky bucket
+	var flightData []byte
+	err := p.Retry.Do(ctx, func(ctx context.Context) error {
+		// rate limit
 		res, err := p.Limiter.Allow(ctx, fmt.Sprintf("limit:%s", p.Name),
 			redis_rate.PerSecond(p.RateLimitRPS))
 		if err != nil {
-			return nil, fmt.Errorf("failed to rate limit: %w", err)
+			return fmt.Errorf("failed to rate limit: %w", err)
 		}
 
 		if res.Allowed == 0 {
-			return nil, providerutils.ErrProviderRateLimitExceeded
+			return providerutils.ErrProviderRateLimitExceeded
 		}
 
-		// get response from mock file
-		flightData, err := os.ReadFile(p.SearchAPIURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read mock file: %w", err)
-		}
+		flightData, err = p.Transport.Do(ctx, p.searchRequest(criteria))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call airasia flight search API: %w", err)
+	}
 
-		var response SearchFlightResponse
-		if err := json.Unmarshal(flightData, &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal mock file: %w", err)
-		}
+	var response SearchFlightResponse
+	if err := json.Unmarshal(flightData, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flight search response: %w", err)
+	}
 
-		// convert to dto.Flight and filter
-		flights := p.flightToDTO(response.Flights)
+	// convert to dto.Flight and filter
+	flights := p.flightToDTO(response.Flights)
 
-		return providerutils.FilterFlights(flights, criteria), nil
-	}
+	return providerutils.FilterFlights(flights, criteria), nil
+}
 
-	return nil, fmt.Errorf("failed after retries: %w", providerutils.ErrRetryExceeded)
+// searchRequest builds the AirAsia flight search API request
+func (p *Provider) searchRequest(criteria dto.SearchCriteria) providerutils.Request {
+	params := providerutils.NewSearchParams(criteria)
+
+	return providerutils.Request{
+		Method: http.MethodGet,
+		Path:   "/flights/search",
+		Query: url.Values{
+			"origin":      {params.Origin},
+			"destination": {params.Destination},
+			"depart_date": {criteria.DepartureDate},
+			"adults":      {strconv.Itoa(criteria.SeatCount())},
+			"cabin":       {params.CabinClass},
+		},
+	}
 }
 
 // flightToDTO converts a slice of Flight to a slice of dto.Flight
@@ -116,26 +112,26 @@ func (p *Provider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]d
 func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 	results := make([]dto.Flight, len(flights))
 	for i, flight := range flights {
+		operatingAirline := providerutils.OperatingAirline(flight.Airline, "",
+			dto.Airline{Name: ProviderName, Code: ProviderCode})
+		layovers := p.parseLayovers(flight.Stops)
+
 		results[i] = dto.Flight{
-			ID:       p.generateID(flight.FlightCode, p.Name),
-			Provider: p.Name,
-			Airline: dto.Airline{
-				Name: ProviderName,
-				Code: ProviderCode,
-			},
-			FlightNumber: flight.FlightCode,
-			Departure: dto.Departure{
-				City:      providerutils.MapAirportToCity[flight.FromAirport],
+			ID:               p.generateID(flight.FlightCode, p.Name),
+			Provider:         p.Name,
+			Airline:          providerutils.MarketingAirline(flight.FlightCode, operatingAirline),
+			OperatingAirline: operatingAirline,
+			FlightNumber:     flight.FlightCode,
+			Departure: providerutils.EnrichDeparture(dto.Departure{
 				Airport:   flight.FromAirport,
 				Datetime:  flight.DepartTime.Format(time.RFC3339),
 				Timestamp: flight.DepartTime.Unix(),
-			},
-			Arrival: dto.Arrival{
-				City:      providerutils.MapAirportToCity[flight.ToAirport],
+			}),
+			Arrival: providerutils.EnrichArrival(dto.Arrival{
 				Airport:   flight.ToAirport,
 				Datetime:  flight.ArriveTime.Format(time.RFC3339),
 				Timestamp: flight.ArriveTime.Unix(),
-			},
+			}),
 			Duration: dto.Duration{
 				TotalMinutes: int(flight.DurationHours * 60),
 				Formatted:    utils.ConvertHourToDuration(flight.DurationHours),
@@ -144,19 +140,35 @@ func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 			Price: dto.Price{
 				Amount:    float64(flight.PriceIDR),
 				Currency:  providerutils.CurrencyIDR,
-				Formatted: utils.FormatRupiah(int64(flight.PriceIDR)),
+				Formatted: currency.Format(float64(flight.PriceIDR), providerutils.CurrencyIDR),
+				Breakdown: providerutils.TotalFare(float64(flight.PriceIDR)),
 			},
 			AvailableSeats: flight.Seats,
-			CabinClass:     flight.CabinClass,
+			CabinClass:     providerutils.NormalizeCabinClass(flight.CabinClass),
 			Aircraft:       nil,
 			Amenities:      []string{},
 			Baggage:        p.parseBaggage(flight.BaggageNote),
+			Layovers:       layovers,
 		}
+		results[i].Segments = providerutils.BuildSegments(results[i], layovers)
 	}
 
 	return results
 }
 
+func (p *Provider) parseLayovers(stops []Stop) []dto.Layover {
+	if len(stops) == 0 {
+		return nil
+	}
+
+	layovers := make([]dto.Layover, len(stops))
+	for i, stop := range stops {
+		layovers[i] = providerutils.NewLayover(stop.Airport, stop.WaitTimeMinutes)
+	}
+
+	return layovers
+}
+
 func (p *Provider) getStops(flight Flight) int {
 	if flight.DirectFlight {
 		return 0
@@ -170,14 +182,5 @@ func (p *Provider) generateID(code, name string) string {
 }
 
 func (p *Provider) parseBaggage(note string) dto.Baggage {
-	baggage := dto.Baggage{}
-	if strings.Contains(note, "Cabin baggage only") {
-		baggage.CarryOn = "Cabin baggage only"
-	}
-
-	if strings.Contains(note, "checked bags additional fee") {
-		baggage.Checked = "Additional fee"
-	}
-
-	return baggage
+	return providerutils.ParseBaggageNote(note)
 }
@@ -0,0 +1,112 @@
+//go:build unit
+
+package airasia
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// fixtureFlights maps the flights of the AirAsia fixture by flight number
+func fixtureFlights(t *testing.T) map[string]dto.Flight {
+	t.Helper()
+
+	data, err := os.ReadFile("../../../../tests/mockprovider/airasia_search_response.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var response SearchFlightResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	p := &Provider{Name: ProviderName}
+	flights := map[string]dto.Flight{}
+	for _, f := range p.flightToDTO(response.Flights) {
+		flights[f.FlightNumber] = f
+	}
+
+	return flights
+}
+
+// routes lists every segment as its flight number and airports
+func routes(segments []dto.Segment) []string {
+	if segments == nil {
+		return nil
+	}
+
+	results := make([]string, len(segments))
+	for i, s := range segments {
+		results[i] = s.FlightNumber + " " + s.Departure.Airport + "-" + s.Arrival.Airport
+	}
+
+	return results
+}
+
+func TestProvider_FlightToDTO_Segments_Closure(t *testing.T) {
+	mapFlight := func(f dto.Flight, wantStops int, wantRoutes []string, wantLayovers []dto.Layover) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(wantStops, f.Stops); diff != "" {
+				t.Fatalf("flightToDTO stops mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantRoutes, routes(f.Segments)); diff != "" {
+				t.Fatalf("flightToDTO segments mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantLayovers, f.Layovers); diff != "" {
+				t.Fatalf("flightToDTO layovers mismatch (-want +got):\n%s", diff)
+			}
+
+			// the first segment leaves like the flight and the last one lands like it
+			if len(f.Segments) > 0 {
+				if diff := cmp.Diff(f.Departure, f.Segments[0].Departure); diff != "" {
+					t.Fatalf("flightToDTO first segment departure mismatch (-want +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff(f.Arrival, f.Segments[len(f.Segments)-1].Arrival); diff != "" {
+					t.Fatalf("flightToDTO last segment arrival mismatch (-want +got):\n%s", diff)
+				}
+			}
+		}
+	}
+
+	flights := fixtureFlights(t)
+
+	t.Run("direct", mapFlight(flights["QZ520"], 0, nil, nil))
+
+	t.Run("one_stop", mapFlight(flights["QZ7250"], 1,
+		[]string{"QZ7250 CGK-SOC", "QZ7250 SOC-DPS"},
+		[]dto.Layover{
+			{Airport: "SOC", City: "Surakarta", Duration: dto.Duration{TotalMinutes: 95, Formatted: "1h 35m"}},
+		},
+	))
+
+	p := &Provider{Name: ProviderName}
+	multiStop := p.flightToDTO([]Flight{{
+		FlightCode:  "QZ900",
+		Airline:     "AirAsia",
+		FromAirport: "KNO",
+		ToAirport:   "DPS",
+		DepartTime:  time.Date(2025, 12, 15, 6, 0, 0, 0, time.FixedZone("WIB", 7*3600)),
+		ArriveTime:  time.Date(2025, 12, 15, 15, 0, 0, 0, time.FixedZone("WITA", 8*3600)),
+		Stops: []Stop{
+			{Airport: "CGK", WaitTimeMinutes: 60},
+			{Airport: "SUB", WaitTimeMinutes: 45},
+		},
+	}})[0]
+
+	t.Run("multi_stop", mapFlight(multiStop, 2,
+		[]string{"QZ900 KNO-CGK", "QZ900 CGK-SUB", "QZ900 SUB-DPS"},
+		[]dto.Layover{
+			{Airport: "CGK", City: "Jakarta", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}},
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 45, Formatted: "45m"}},
+		},
+	))
+}
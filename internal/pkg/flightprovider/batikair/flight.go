@@ -1,5 +1,15 @@
 package batikair
 
+import "github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+
+type SearchFlightRequest struct {
+	Origin        string `json:"origin"`
+	Destination   string `json:"destination"`
+	DepartureDate string `json:"departureDate"`
+	Passengers    int    `json:"passengers"`
+	CabinClass    string `json:"cabinClass"`
+}
+
 type SearchFlightResponse struct {
 	Code    int      `json:"code"`
 	Message string   `json:"message"`
@@ -36,3 +46,16 @@ type Fare struct {
 	CurrencyCode string `json:"currencyCode"`
 	Class        string `json:"class"`
 }
+
+// amenityMapping maps the batik air onboard services to the canonical amenities
+var amenityMapping = map[string]string{
+	"wifi":          dto.AmenityWifi,
+	"meal":          dto.AmenityMeal,
+	"hot meal":      dto.AmenityMeal,
+	"snack":         dto.AmenitySnack,
+	"beverage":      dto.AmenityBeverage,
+	"drinks":        dto.AmenityBeverage,
+	"entertainment": dto.AmenityEntertainment,
+	"power":         dto.AmenityPower,
+	"usb":           dto.AmenityUSB,
+}
@@ -5,13 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
-	"math/rand"
-	"os"
-	"strings"
+	"net/http"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
@@ -24,22 +23,33 @@ const (
 
 type Provider struct {
 	Name         string
-	SearchAPIURL string
+	Transport    providerutils.Transport
 	Timeout      time.Duration
-	MaxRetries   int
+	Retry        *flightprovider.RetryPolicy
 	Limiter      *redis_rate.Limiter
 	RateLimitRPS int
 }
 
-func NewProvider(config flightprovider.FlightProviderConfig) *Provider {
+func NewProvider(config flightprovider.FlightProviderConfig) (*Provider, error) {
+	transport, err := providerutils.NewTransport(providerutils.TransportConfig{
+		Mode:             config.Mode,
+		URL:              config.SearchAPIURL,
+		Auth:             providerutils.APIKeyAuth("X-Api-Key", config.APIKey),
+		Client:           config.HTTPClient,
+		MaxResponseBytes: config.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %w", ProviderName, err)
+	}
+
 	return &Provider{
 		Name:         ProviderName,
-		SearchAPIURL: config.SearchAPIURL,
+		Transport:    transport,
 		Timeout:      config.Timeout,
-		MaxRetries:   config.MaxRetries,
+		Retry:        flightprovider.NewRetryPolicy(ProviderName, config),
 		Limiter:      config.Limiter,
 		RateLimitRPS: config.RateLimitRPS,
-	}
+	}, nil
 }
 
 // flightToDTO converts a slice of Flight to a slice of dto.Flight
@@ -47,6 +57,8 @@ func NewProvider(config flightprovider.FlightProviderConfig) *Provider {
 func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 	results := make([]dto.Flight, len(flights))
 	for i, flight := range flights {
+		operatingAirline := providerutils.OperatingAirline(flight.AirlineName, flight.AirlineIATA,
+			dto.Airline{Name: ProviderName, Code: ProviderCode})
 
 		departureTime, err := p.parseTime(flight.DepartureDateTime)
 		if err != nil {
@@ -61,49 +73,57 @@ func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 
 		durationFormat, duration := p.getActualDuration(flight.TravelTime,
 			flight.Connections)
+		layovers := p.parseLayovers(flight.Connections)
 
 		results[i] = dto.Flight{
-			ID:       p.generateID(flight.FlightNumber, ProviderName),
-			Provider: p.Name,
-			Airline: dto.Airline{
-				Name: ProviderName,
-				Code: ProviderCode,
-			},
-			FlightNumber: flight.FlightNumber,
-			Departure: dto.Departure{
-				City:      providerutils.MapAirportToCity[flight.Origin],
+			ID:               p.generateID(flight.FlightNumber, ProviderName),
+			Provider:         p.Name,
+			Airline:          providerutils.MarketingAirline(flight.FlightNumber, operatingAirline),
+			OperatingAirline: operatingAirline,
+			FlightNumber:     flight.FlightNumber,
+			Departure: providerutils.EnrichDeparture(dto.Departure{
 				Airport:   flight.Origin,
 				Datetime:  departureTime.Format(time.RFC3339),
 				Timestamp: departureTime.Unix(),
-			},
-			Arrival: dto.Arrival{
-				City:      providerutils.MapAirportToCity[flight.Destination],
+			}),
+			Arrival: providerutils.EnrichArrival(dto.Arrival{
 				Airport:   flight.Destination,
 				Datetime:  arrivalTime.Format(time.RFC3339),
 				Timestamp: arrivalTime.Unix(),
-			},
+			}),
 			Duration: dto.Duration{
 				TotalMinutes: duration,
 				Formatted:    durationFormat,
 			},
-			Stops: flight.NumberOfStops,
-			Price: dto.Price{
-				Amount:   float64(p.getTotalPrice(flight.Fare.BasePrice, flight.Fare.Taxes)),
-				Currency: flight.Fare.CurrencyCode,
-				Formatted: utils.FormatRupiah(p.getTotalPrice(flight.Fare.BasePrice,
-					flight.Fare.Taxes)),
-			},
+			Stops:          flight.NumberOfStops,
+			Price:          p.getPrice(flight.Fare),
 			AvailableSeats: flight.SeatsAvailable,
-			CabinClass:     p.getCabinClass(flight.Fare.Class),
+			CabinClass:     providerutils.NormalizeCabinClass(flight.Fare.Class),
 			Aircraft:       &flight.AircraftModel,
 			Amenities:      p.getAmenities(flight.OnboardServices),
 			Baggage:        p.parseBaggage(flight.BaggageInfo),
+			Layovers:       layovers,
 		}
+		results[i].Segments = providerutils.BuildSegments(results[i], layovers)
 	}
 
 	return results
 }
 
+func (p *Provider) parseLayovers(connections []Connection) []dto.Layover {
+	if len(connections) == 0 {
+		return nil
+	}
+
+	layovers := make([]dto.Layover, len(connections))
+	for i, conn := range connections {
+		layovers[i] = providerutils.NewLayover(conn.StopAirport,
+			int(utils.ConvertDurationToMinutes(conn.StopDuration)))
+	}
+
+	return layovers
+}
+
 func (p *Provider) getActualDuration(travelTime string, connection []Connection) (string, int) {
 	totalMinutes := utils.ConvertDurationToMinutes(travelTime)
 	for _, conn := range connection {
@@ -113,72 +133,58 @@ func (p *Provider) getActualDuration(travelTime string, connection []Connection)
 	return utils.ConvertMinutesToDuration(totalMinutes), int(totalMinutes)
 }
 
-// Search will simulate API call to AirAsia flight search API
-// it will return always success with delay 200-400ms
+// Search calls the BatikAir flight search API, or reads the fixture file in file mode,
+// failed calls are retried with the retry policy of the provider
 func (p *Provider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
 	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
 	defer cancel()
 
-	var lastErr error
-	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
-		// simulate delay 200-400ms
-		delay := time.Duration(200+rand.Intn(201)) * time.Millisecond
-		select {
-		case <-time.After(delay):
-		case <-ctx.Done():
-			return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
-		}
-
-		// always success not simulate error
-		if rand.Float64() < 0 {
-			lastErr = providerutils.ErrProviderInternalError
-			slog.ErrorContext(ctx, "failed to call batikair flight search API", "attempt",
-				attempt+1, "error", lastErr)
-
-			if attempt < p.MaxRetries {
-				// Exponential backoff: 200ms * 2^attempt
-				backoff := time.Duration(200*(1<<attempt)) * time.Millisecond
-				slog.InfoContext(ctx, "retrying with exponential backoff", "backoff",
-					backoff, "next_attempt", attempt+2)
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				}
-			}
-			continue
-		}
-
+	var flightData []byte
+	err := p.Retry.Do(ctx, func(ctx context.Context) error {
 		// rate limit
 		res, err := p.Limiter.Allow(ctx, fmt.Sprintf("limit:%s", p.Name),
 			redis_rate.PerSecond(p.RateLimitRPS))
 		if err != nil {
-			return nil, fmt.Errorf("failed to rate limit: %w", err)
+			return fmt.Errorf("failed to rate limit: %w", err)
 		}
 
 		if res.Allowed == 0 {
-			return nil, providerutils.ErrProviderRateLimitExceeded
+			return providerutils.ErrProviderRateLimitExceeded
 		}
 
-		// get response from mock file
-		flightData, err := os.ReadFile(p.SearchAPIURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read mock file: %w", err)
-		}
+		flightData, err = p.Transport.Do(ctx, p.searchRequest(criteria))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call batikair flight search API: %w", err)
+	}
 
-		var response SearchFlightResponse
-		if err := json.Unmarshal(flightData, &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal mock file: %w", err)
-		}
+	var response SearchFlightResponse
+	if err := json.Unmarshal(flightData, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flight search response: %w", err)
+	}
 
-		// convert to dto.Flight and filter
-		flights := p.flightToDTO(response.Results)
+	// convert to dto.Flight and filter
+	flights := p.flightToDTO(response.Results)
 
-		return providerutils.FilterFlights(flights, criteria), nil
-	}
+	return providerutils.FilterFlights(flights, criteria), nil
+}
 
-	return nil, fmt.Errorf("failed to get flight data after %d attempts", p.MaxRetries)
+// searchRequest builds the BatikAir flight search API request
+func (p *Provider) searchRequest(criteria dto.SearchCriteria) providerutils.Request {
+	params := providerutils.NewSearchParams(criteria)
+
+	return providerutils.Request{
+		Method: http.MethodPost,
+		Path:   "/api/v1/search",
+		Body: SearchFlightRequest{
+			Origin:        params.Origin,
+			Destination:   params.Destination,
+			DepartureDate: criteria.DepartureDate,
+			Passengers:    criteria.SeatCount(),
+			CabinClass:    params.CabinClass,
+		},
+	}
 }
 
 func (p *Provider) parseTime(timeString string) (time.Time, error) {
@@ -191,50 +197,31 @@ func (p *Provider) parseTime(timeString string) (time.Time, error) {
 }
 
 func (p *Provider) getAmenities(amenities []string) []string {
-	for i := range amenities {
-		amenities[i] = strings.ToLower(amenities[i])
-	}
-
-	return amenities
+	return providerutils.NormalizeAmenities(amenities, amenityMapping)
 }
 
 func (p *Provider) parseBaggage(note string) dto.Baggage {
-	baggage := dto.Baggage{}
-	noteSplit := strings.Split(note, ",")
-	for _, n := range noteSplit {
-		if strings.Contains(n, "cabin") {
-			baggage.CarryOn = n
-		}
-
-		if strings.Contains(n, "checked") {
-			baggage.Checked = n
-		}
-	}
-
-	return baggage
+	return providerutils.ParseBaggageNote(note)
 }
 func (p *Provider) generateID(code, name string) string {
 	return fmt.Sprintf("%s_%s", code, name)
 }
 
-func (p *Provider) getCabinClass(code string) string {
-	code = strings.ToUpper(strings.TrimSpace(code))
-
-	classMap := map[string]string{
-		"Y": "economy",
-		"W": "premium_economy",
-		"C": "business",
-		"J": "business",
-		"F": "first",
+// getPrice keeps the reported base fare and taxes, anything else in the
+// total price is a surcharge
+func (p *Provider) getPrice(fare Fare) dto.Price {
+	total := fare.BasePrice + fare.Taxes
+	surcharges := 0
+	if fare.TotalPrice > total {
+		surcharges = fare.TotalPrice - total
+		total = fare.TotalPrice
 	}
 
-	if class, ok := classMap[code]; ok {
-		return class
+	return dto.Price{
+		Amount:    float64(total),
+		Currency:  fare.CurrencyCode,
+		Formatted: currency.Format(float64(total), fare.CurrencyCode),
+		Breakdown: providerutils.ReportedFare(float64(fare.BasePrice), float64(fare.Taxes),
+			float64(surcharges), 0),
 	}
-
-	return "economy"
-}
-
-func (p *Provider) getTotalPrice(basePrice int, taxes int) int64 {
-	return int64(basePrice + taxes)
 }
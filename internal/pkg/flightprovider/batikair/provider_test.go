@@ -0,0 +1,174 @@
+//go:build unit
+
+package batikair
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// fixtureFlights maps the flights of the BatikAir fixture by flight number
+func fixtureFlights(t *testing.T) map[string]dto.Flight {
+	t.Helper()
+
+	data, err := os.ReadFile("../../../../tests/mockprovider/batik_air_search_response.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var response SearchFlightResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	p := &Provider{Name: ProviderName}
+	flights := map[string]dto.Flight{}
+	for _, f := range p.flightToDTO(response.Results) {
+		flights[f.FlightNumber] = f
+	}
+
+	return flights
+}
+
+// routes lists every segment as its flight number and airports
+func routes(segments []dto.Segment) []string {
+	if segments == nil {
+		return nil
+	}
+
+	results := make([]string, len(segments))
+	for i, s := range segments {
+		results[i] = s.FlightNumber + " " + s.Departure.Airport + "-" + s.Arrival.Airport
+	}
+
+	return results
+}
+
+func TestProvider_FlightToDTO_Segments_Closure(t *testing.T) {
+	mapFlight := func(f dto.Flight, wantStops int, wantRoutes []string, wantLayovers []dto.Layover) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(wantStops, f.Stops); diff != "" {
+				t.Fatalf("flightToDTO stops mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantRoutes, routes(f.Segments)); diff != "" {
+				t.Fatalf("flightToDTO segments mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantLayovers, f.Layovers); diff != "" {
+				t.Fatalf("flightToDTO layovers mismatch (-want +got):\n%s", diff)
+			}
+
+			// the first segment leaves like the flight and the last one lands like it
+			if len(f.Segments) > 0 {
+				if diff := cmp.Diff(f.Departure, f.Segments[0].Departure); diff != "" {
+					t.Fatalf("flightToDTO first segment departure mismatch (-want +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff(f.Arrival, f.Segments[len(f.Segments)-1].Arrival); diff != "" {
+					t.Fatalf("flightToDTO last segment arrival mismatch (-want +got):\n%s", diff)
+				}
+			}
+		}
+	}
+
+	flights := fixtureFlights(t)
+
+	t.Run("direct", mapFlight(flights["ID6514"], 0, nil, nil))
+
+	t.Run("one_stop", mapFlight(flights["ID7042"], 1,
+		[]string{"ID7042 CGK-UPG", "ID7042 UPG-DPS"},
+		[]dto.Layover{
+			{Airport: "UPG", City: "Makassar", Duration: dto.Duration{TotalMinutes: 55, Formatted: "55m"}},
+		},
+	))
+
+	p := &Provider{Name: ProviderName}
+	multiStop := p.flightToDTO([]Flight{{
+		FlightNumber:      "ID900",
+		AirlineName:       "Batik Air",
+		AirlineIATA:       "ID",
+		Origin:            "KNO",
+		Destination:       "DPS",
+		DepartureDateTime: "2025-12-15T06:00:00+0700",
+		ArrivalDateTime:   "2025-12-15T15:00:00+0800",
+		TravelTime:        "6h 15m",
+		NumberOfStops:     2,
+		Connections: []Connection{
+			{StopAirport: "CGK", StopDuration: "1h"},
+			{StopAirport: "SUB", StopDuration: "45m"},
+		},
+	}})[0]
+
+	t.Run("multi_stop", mapFlight(multiStop, 2,
+		[]string{"ID900 KNO-CGK", "ID900 CGK-SUB", "ID900 SUB-DPS"},
+		[]dto.Layover{
+			{Airport: "CGK", City: "Jakarta", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}},
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 45, Formatted: "45m"}},
+		},
+	))
+
+	// the travel time leaves the connections out, the duration is the whole journey
+	t.Run("duration_includes_layovers", func(t *testing.T) {
+		if diff := cmp.Diff(240, flights["ID7042"].Duration.TotalMinutes); diff != "" {
+			t.Fatalf("flightToDTO duration mismatch (-want +got):\n%s", diff)
+		}
+
+		if diff := cmp.Diff(480, multiStop.Duration.TotalMinutes); diff != "" {
+			t.Fatalf("flightToDTO duration mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestProvider_GetAmenities_Closure(t *testing.T) {
+	p := &Provider{Name: ProviderName}
+
+	amenitiesRequest := func(amenities []string, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, p.getAmenities(amenities)); diff != "" {
+				t.Fatalf("getAmenities(%v) result mismatch (-want +got):\n%s", amenities, diff)
+			}
+		}
+	}
+
+	t.Run("meal_and_drinks", amenitiesRequest([]string{"Hot Meal", "Drinks", "Snack"},
+		[]string{dto.AmenityMeal, dto.AmenityBeverage, dto.AmenitySnack}))
+	t.Run("meal_spellings", amenitiesRequest([]string{"meal", "hot meal"}, []string{dto.AmenityMeal}))
+	t.Run("unknown_is_dropped", amenitiesRequest([]string{"Blanket", "WiFi"}, []string{dto.AmenityWifi}))
+}
+
+func TestProvider_FlightToDTO_CabinClass_Closure(t *testing.T) {
+	p := &Provider{Name: ProviderName}
+
+	cabinRequest := func(class, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			f := p.flightToDTO([]Flight{{
+				FlightNumber:      "ID6514",
+				AirlineIATA:       "ID",
+				Origin:            "CGK",
+				Destination:       "DPS",
+				DepartureDateTime: "2025-12-15T07:15:00+0700",
+				ArrivalDateTime:   "2025-12-15T10:00:00+0800",
+				TravelTime:        "1h 45m",
+				Fare:              Fare{Class: class},
+			}})[0]
+
+			if diff := cmp.Diff(want, f.CabinClass); diff != "" {
+				t.Fatalf("flightToDTO cabin class of %q mismatch (-want +got):\n%s", class, diff)
+			}
+		}
+	}
+
+	t.Run("economy", cabinRequest("Y", dto.CabinEconomy))
+	t.Run("premium_economy", cabinRequest("W", dto.CabinPremiumEconomy))
+	t.Run("business", cabinRequest("C", dto.CabinBusiness))
+	t.Run("business_j", cabinRequest("j", dto.CabinBusiness))
+	t.Run("first", cabinRequest(" F ", dto.CabinFirst))
+	t.Run("cabin_name", cabinRequest("Business", dto.CabinBusiness))
+	// an unknown booking class isn't taken for economy
+	t.Run("unknown_class", cabinRequest("Q", "q"))
+}
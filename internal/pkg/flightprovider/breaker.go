@@ -0,0 +1,195 @@
+package flightprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+)
+
+type CircuitState int
+
+// states of the circuit breaker, closed calls the provider, open skips it
+// and half-open lets a few probe calls through to decide whether it recovered
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+
+	return "closed"
+}
+
+// CircuitBreakerConfig opens the circuit after FailureThreshold consecutive failures,
+// keeps it open for OpenTimeout, then lets HalfOpenMaxCalls probe calls through at a time
+// and closes it again after SuccessThreshold of them succeed
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+	HalfOpenMaxCalls int
+	SuccessThreshold int
+}
+
+// CircuitBreaker skips a failing provider instead of waiting for its timeout and retries,
+// skipped searches fail with providerutils.ErrCircuitOpen
+type CircuitBreaker struct {
+	Name     string
+	Provider FlightProvider
+
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu            sync.Mutex
+	state         CircuitState
+	failures      int
+	successes     int
+	halfOpenCalls int
+	openedAt      time.Time
+
+	// generation changes with every transition, so the result of a call admitted
+	// in a previous state is told apart from the calls of the current state
+	generation uint64
+}
+
+func NewCircuitBreaker(name string, provider FlightProvider, config CircuitBreakerConfig) *CircuitBreaker {
+	if config.HalfOpenMaxCalls <= 0 {
+		config.HalfOpenMaxCalls = 1
+	}
+
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = 1
+	}
+
+	return &CircuitBreaker{
+		Name:     name,
+		Provider: provider,
+		config:   config,
+		now:      time.Now,
+	}
+}
+
+func (b *CircuitBreaker) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
+	generation, ok := b.allow(ctx)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", b.Name, providerutils.ErrCircuitOpen)
+	}
+
+	flights, err := b.Provider.Search(ctx, criteria)
+	b.record(ctx, generation, err)
+
+	return flights, err
+}
+
+// State returns the current state of the circuit
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+// allow reports whether the call may go through and the generation of the state it is admitted in
+func (b *CircuitBreaker) allow(ctx context.Context) (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen {
+		if b.now().Sub(b.openedAt) < b.config.OpenTimeout {
+			return b.generation, false
+		}
+
+		b.transition(ctx, CircuitHalfOpen)
+	}
+
+	if b.state == CircuitHalfOpen {
+		if b.halfOpenCalls >= b.config.HalfOpenMaxCalls {
+			return b.generation, false
+		}
+
+		b.halfOpenCalls++
+	}
+
+	return b.generation, true
+}
+
+// record counts the result of a call admitted in the generation, a call that straddles
+// a transition is ignored so it neither frees a probe slot nor decides the new state
+func (b *CircuitBreaker) record(ctx context.Context, generation uint64, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	if b.state == CircuitHalfOpen {
+		b.halfOpenCalls--
+	}
+
+	if err != nil && !isProviderFailure(ctx, err) {
+		return
+	}
+
+	switch b.state {
+	case CircuitClosed:
+		if err == nil {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+		if b.failures >= b.config.FailureThreshold {
+			b.transition(ctx, CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if err != nil {
+			b.transition(ctx, CircuitOpen)
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.config.SuccessThreshold {
+			b.transition(ctx, CircuitClosed)
+		}
+	}
+}
+
+// transition changes the state and resets its counters, the lock must be held
+func (b *CircuitBreaker) transition(ctx context.Context, state CircuitState) {
+	slog.WarnContext(ctx, "provider circuit breaker state changed", "provider", b.Name,
+		"from", b.state.String(), "to", state.String())
+
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.halfOpenCalls = 0
+	if state == CircuitOpen {
+		b.openedAt = b.now()
+	}
+}
+
+// isProviderFailure reports whether the error means the provider is unhealthy, a search cancelled
+// by the client, our own rate limit and rejected requests don't count. a provider still answering at
+// the search deadline counts like its own timeout, otherwise a hung provider never opens the circuit
+func isProviderFailure(ctx context.Context, err error) bool {
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return false
+	}
+
+	return !errors.Is(err, providerutils.ErrProviderRateLimitExceeded) &&
+		!errors.Is(err, providerutils.ErrProviderBadRequest)
+}
@@ -0,0 +1,211 @@
+//go:build unit
+
+package flightprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCircuitBreaker_Closure(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	}
+
+	// step is a search through the breaker, after moving the clock by elapsed
+	type step struct {
+		elapsed     time.Duration
+		providerErr error
+		wantCalled  bool
+		wantState   CircuitState
+	}
+
+	breakerRequest := func(steps []step) func(t *testing.T) {
+		return func(t *testing.T) {
+			provider := NewMockFlightProvider(t)
+			breaker := NewCircuitBreaker("test-provider", provider, config)
+
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			breaker.now = func() time.Time { return now }
+
+			for i, s := range steps {
+				now = now.Add(s.elapsed)
+
+				if s.wantCalled {
+					provider.On("Search", mock.Anything, mock.Anything).Return([]dto.Flight{}, s.providerErr).Once()
+				}
+
+				_, err := breaker.Search(context.Background(), dto.SearchCriteria{})
+
+				if !s.wantCalled && !errors.Is(err, providerutils.ErrCircuitOpen) {
+					t.Fatalf("step %d: expected circuit open error, got %v", i, err)
+				}
+
+				if diff := cmp.Diff(s.wantState.String(), breaker.State().String()); diff != "" {
+					t.Fatalf("step %d: CircuitBreaker state mismatch (-want +got):\n%s", i, diff)
+				}
+			}
+		}
+	}
+
+	failure := providerutils.ErrProviderInternalError
+
+	t.Run("opens_after_consecutive_failures", breakerRequest([]step{
+		{providerErr: failure, wantCalled: true, wantState: CircuitClosed},
+		{providerErr: failure, wantCalled: true, wantState: CircuitOpen},
+		{wantCalled: false, wantState: CircuitOpen},
+	}))
+
+	t.Run("success_resets_failures", breakerRequest([]step{
+		{providerErr: failure, wantCalled: true, wantState: CircuitClosed},
+		{wantCalled: true, wantState: CircuitClosed},
+		{providerErr: failure, wantCalled: true, wantState: CircuitClosed},
+	}))
+
+	t.Run("rejected_requests_are_not_failures", breakerRequest([]step{
+		{providerErr: providerutils.ErrProviderBadRequest, wantCalled: true, wantState: CircuitClosed},
+		{providerErr: providerutils.ErrProviderRateLimitExceeded, wantCalled: true, wantState: CircuitClosed},
+	}))
+
+	t.Run("half_open_probe_closes", breakerRequest([]step{
+		{providerErr: failure, wantCalled: true, wantState: CircuitClosed},
+		{providerErr: failure, wantCalled: true, wantState: CircuitOpen},
+		{elapsed: time.Minute, wantCalled: true, wantState: CircuitClosed},
+	}))
+
+	t.Run("half_open_probe_failure_reopens", breakerRequest([]step{
+		{providerErr: failure, wantCalled: true, wantState: CircuitClosed},
+		{providerErr: failure, wantCalled: true, wantState: CircuitOpen},
+		{elapsed: time.Minute, providerErr: failure, wantCalled: true, wantState: CircuitOpen},
+		{elapsed: time.Second, wantCalled: false, wantState: CircuitOpen},
+	}))
+}
+
+func TestCircuitBreaker_StraddlingCall(t *testing.T) {
+	provider := NewMockFlightProvider(t)
+	breaker := NewCircuitBreaker("test-provider", provider, CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	})
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+
+	failure := providerutils.ErrProviderInternalError
+
+	// blockingSearch starts a search that returns err once it is released
+	blockingSearch := func(err error) (release chan struct{}, done chan error) {
+		started, release, done := make(chan struct{}), make(chan struct{}), make(chan error)
+		provider.On("Search", mock.Anything, mock.Anything).Return([]dto.Flight{}, err).
+			Run(func(mock.Arguments) {
+				close(started)
+				<-release
+			}).Once()
+
+		go func() {
+			_, err := breaker.Search(context.Background(), dto.SearchCriteria{})
+			done <- err
+		}()
+		<-started
+
+		return release, done
+	}
+
+	assertState := func(want CircuitState) {
+		t.Helper()
+		if diff := cmp.Diff(want.String(), breaker.State().String()); diff != "" {
+			t.Fatalf("CircuitBreaker state mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// a call admitted while closed is still in flight when the circuit opens
+	releaseStale, staleDone := blockingSearch(failure)
+
+	provider.On("Search", mock.Anything, mock.Anything).Return([]dto.Flight{}, failure).Twice()
+	_, _ = breaker.Search(context.Background(), dto.SearchCriteria{})
+	_, _ = breaker.Search(context.Background(), dto.SearchCriteria{})
+	assertState(CircuitOpen)
+
+	// the probe takes the only half-open slot
+	now = now.Add(time.Minute)
+	releaseProbe, probeDone := blockingSearch(nil)
+	assertState(CircuitHalfOpen)
+
+	// the stale failure neither reopens the circuit nor frees the probe slot
+	close(releaseStale)
+	<-staleDone
+	assertState(CircuitHalfOpen)
+
+	if _, err := breaker.Search(context.Background(), dto.SearchCriteria{}); !errors.Is(err, providerutils.ErrCircuitOpen) {
+		t.Fatalf("expected circuit open error while the probe is in flight, got %v", err)
+	}
+
+	close(releaseProbe)
+	if err := <-probeDone; err != nil {
+		t.Fatalf("probe unexpected error: %v", err)
+	}
+	assertState(CircuitClosed)
+}
+
+func TestCircuitBreaker_SearchDeadline_Closure(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      time.Minute,
+		HalfOpenMaxCalls: 1,
+		SuccessThreshold: 1,
+	}
+
+	// hangingSearch searches a provider that only answers once the search is done
+	hangingSearch := func(newContext func() (context.Context, context.CancelFunc),
+		wantState CircuitState) func(t *testing.T) {
+		return func(t *testing.T) {
+			provider := NewMockFlightProvider(t)
+			provider.On("Search", mock.Anything, mock.Anything).
+				Return(func(ctx context.Context, _ dto.SearchCriteria) ([]dto.Flight, error) {
+					<-ctx.Done()
+					return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
+				}).Times(config.FailureThreshold)
+
+			breaker := NewCircuitBreaker("test-provider", provider, config)
+
+			for i := 0; i < config.FailureThreshold; i++ {
+				ctx, cancel := newContext()
+				_, _ = breaker.Search(ctx, dto.SearchCriteria{})
+				cancel()
+			}
+
+			if diff := cmp.Diff(wantState.String(), breaker.State().String()); diff != "" {
+				t.Fatalf("CircuitBreaker state mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("hung_provider_past_the_search_deadline_opens", hangingSearch(
+		func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 10*time.Millisecond)
+		},
+		CircuitOpen,
+	))
+
+	t.Run("client_cancellation_is_not_a_failure", hangingSearch(
+		func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return ctx, cancel
+		},
+		CircuitClosed,
+	))
+}
@@ -2,19 +2,29 @@ package flightprovider
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
 )
 
-// config for flight provider
+// config for flight provider, Mode decides whether SearchAPIURL is a fixture file
+// or the base url of the provider API, see providerutils.NewTransport
 type FlightProviderConfig struct {
+	Mode         string
 	SearchAPIURL string
+	APIKey       string
 	Timeout      time.Duration
 	MaxRetries   int
 	RateLimitRPS int
 	Limiter      *redis_rate.Limiter
+	// Backoff and RetryBudget make the retry policy together with MaxRetries
+	Backoff     Backoff
+	RetryBudget *RetryBudget
+	// HTTPClient is shared by the providers to pool the connections
+	HTTPClient       *http.Client
+	MaxResponseBytes int64
 }
 
 type FlightProvider interface {
@@ -23,6 +33,8 @@ type FlightProvider interface {
 
 type FlightProviderFactory struct {
 	Provider map[string]FlightProvider
+	breaker  *CircuitBreakerConfig
+	hedge    *HedgeConfig
 }
 
 func NewFlightProviderFactory() *FlightProviderFactory {
@@ -31,7 +43,29 @@ func NewFlightProviderFactory() *FlightProviderFactory {
 	}
 }
 
+// WithCircuitBreaker wraps every provider added afterwards in its own circuit breaker
+func (f *FlightProviderFactory) WithCircuitBreaker(config CircuitBreakerConfig) *FlightProviderFactory {
+	f.breaker = &config
+	return f
+}
+
+// WithHedging hedges the slow searches of every provider added afterwards
+func (f *FlightProviderFactory) WithHedging(config HedgeConfig) *FlightProviderFactory {
+	f.hedge = &config
+	return f
+}
+
+// AddProvider adds the provider, the hedging is wrapped inside the circuit breaker
+// so a hedged search counts once for the breaker
 func (f *FlightProviderFactory) AddProvider(name string, provider FlightProvider) {
+	if f.hedge != nil {
+		provider = NewHedgedProvider(name, provider, *f.hedge)
+	}
+
+	if f.breaker != nil {
+		provider = NewCircuitBreaker(name, provider, *f.breaker)
+	}
+
 	f.Provider[name] = provider
 }
 
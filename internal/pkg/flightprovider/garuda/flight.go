@@ -1,6 +1,10 @@
 package garuda
 
-import "time"
+import (
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
 
 type SearchFlightResponse struct {
 	Status  string   `json:"status"`
@@ -48,3 +52,15 @@ type Baggage struct {
 	CarryOn int `json:"carry_on"`
 	Checked int `json:"checked"`
 }
+
+// amenityMapping maps the garuda amenities to the canonical amenities
+var amenityMapping = map[string]string{
+	"wifi":          dto.AmenityWifi,
+	"wi-fi":         dto.AmenityWifi,
+	"meal":          dto.AmenityMeal,
+	"power_outlet":  dto.AmenityPower,
+	"usb_port":      dto.AmenityUSB,
+	"entertainment": dto.AmenityEntertainment,
+	"ife":           dto.AmenityEntertainment,
+	"extra_legroom": dto.AmenityExtraLegroom,
+}
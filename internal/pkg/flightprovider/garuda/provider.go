@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
-	"math/rand"
-	"os"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
@@ -23,139 +24,189 @@ const (
 
 type Provider struct {
 	Name         string
-	SearchAPIURL string
+	Transport    providerutils.Transport
 	Timeout      time.Duration
-	MaxRetries   int
+	Retry        *flightprovider.RetryPolicy
 	Limiter      *redis_rate.Limiter
 	RateLimitRPS int
 }
 
-func NewProvider(config flightprovider.FlightProviderConfig) *Provider {
+func NewProvider(config flightprovider.FlightProviderConfig) (*Provider, error) {
+	transport, err := providerutils.NewTransport(providerutils.TransportConfig{
+		Mode:             config.Mode,
+		URL:              config.SearchAPIURL,
+		Auth:             providerutils.BearerAuth(config.APIKey),
+		Client:           config.HTTPClient,
+		MaxResponseBytes: config.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %w", ProviderName, err)
+	}
+
 	return &Provider{
 		Name:         ProviderName,
-		SearchAPIURL: config.SearchAPIURL,
+		Transport:    transport,
 		Timeout:      config.Timeout,
-		MaxRetries:   config.MaxRetries,
+		Retry:        flightprovider.NewRetryPolicy(ProviderName, config),
 		Limiter:      config.Limiter,
 		RateLimitRPS: config.RateLimitRPS,
-	}
+	}, nil
 }
 
-// Search will simulate API call to Garuda flight search API
-// it will return always success with delay 50-100ms
+// Search calls the Garuda flight search API, or reads the fixture file in file mode,
+// failed calls are retried with the retry policy of the provider
 func (p *Provider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
 	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
 	defer cancel()
 
-	var lastErr error
-	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
-		// simulate delay 100-300ms
-		delay := time.Duration(100+rand.Intn(201)) * time.Millisecond
-		select {
-		case <-time.After(delay):
-		case <-ctx.Done():
-			return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
-		}
-
-		// always success not simulate error
-		if rand.Float64() < 0 {
-			lastErr = providerutils.ErrProviderInternalError
-			slog.ErrorContext(ctx, "failed to call garuda flight search API", "attempt", attempt+1, "error", lastErr)
-
-			if attempt < p.MaxRetries {
-				// Exponential backoff: 200ms * 2^attempt
-				backoff := time.Duration(200*(1<<attempt)) * time.Millisecond
-				slog.InfoContext(ctx, "retrying with exponential backoff", "backoff", backoff, "next_attempt", attempt+2)
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				}
-			}
-			continue
-		}
-
+	var flightData []byte
+	err := p.Retry.Do(ctx, func(ctx context.Context) error {
 		// rate limit
 		res, err := p.Limiter.Allow(ctx, fmt.Sprintf("limit:%s", p.Name),
 			redis_rate.PerSecond(p.RateLimitRPS))
 		if err != nil {
-			return nil, fmt.Errorf("failed to rate limit: %w", err)
+			return fmt.Errorf("failed to rate limit: %w", err)
 		}
 
 		if res.Allowed == 0 {
-			return nil, providerutils.ErrProviderRateLimitExceeded
+			return providerutils.ErrProviderRateLimitExceeded
 		}
 
-		// get response from mock file
-		flightData, err := os.ReadFile(p.SearchAPIURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read mock file: %w", err)
-		}
-
-		var response SearchFlightResponse
-		if err := json.Unmarshal(flightData, &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal mock file: %w", err)
-		}
+		flightData, err = p.Transport.Do(ctx, p.searchRequest(criteria))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call garuda flight search API: %w", err)
+	}
 
-		// convert to dto.Flight and filter
-		flights := p.flightToDTO(response.Flights)
-		return providerutils.FilterFlights(flights, criteria), nil
+	var response SearchFlightResponse
+	if err := json.Unmarshal(flightData, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flight search response: %w", err)
 	}
 
-	return nil, fmt.Errorf("failed to get flight data after %d attempts", p.MaxRetries)
+	// convert to dto.Flight and filter
+	flights := p.flightToDTO(response.Flights)
+
+	return providerutils.FilterFlights(flights, criteria), nil
+}
+
+// searchRequest builds the Garuda flight search API request
+func (p *Provider) searchRequest(criteria dto.SearchCriteria) providerutils.Request {
+	params := providerutils.NewSearchParams(criteria)
+
+	return providerutils.Request{
+		Method: http.MethodGet,
+		Path:   "/v1/flights/search",
+		Query: url.Values{
+			"origin":         {params.Origin},
+			"destination":    {params.Destination},
+			"departure_date": {criteria.DepartureDate},
+			"passengers":     {strconv.Itoa(criteria.SeatCount())},
+			"cabin_class":    {params.CabinClass},
+		},
+	}
 }
 
 func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 	results := make([]dto.Flight, len(flights))
 	for i, flight := range flights {
+		operatingAirline := providerutils.OperatingAirline(flight.Airline, flight.AirlineCode,
+			dto.Airline{Name: ProviderName, Code: ProviderCode})
+		// the stops reported by garuda can leave out the connections listed in the segments
+		segments, layovers := p.parseSegments(flight.Segments)
+
 		results[i] = dto.Flight{
-			ID:       p.generateID(flight.FlightID, p.Name),
-			Provider: p.Name,
-			Airline: dto.Airline{
-				Name: ProviderName,
-				Code: ProviderCode,
-			},
-			FlightNumber: flight.FlightID,
-			Departure: dto.Departure{
+			ID:               p.generateID(flight.FlightID, p.Name),
+			Provider:         p.Name,
+			Airline:          providerutils.MarketingAirline(flight.FlightID, operatingAirline),
+			OperatingAirline: operatingAirline,
+			FlightNumber:     flight.FlightID,
+			Departure: providerutils.EnrichDeparture(dto.Departure{
 				Airport:   flight.Departure.Airport,
 				City:      flight.Departure.City,
+				Terminal:  flight.Departure.Terminal,
 				Datetime:  flight.Departure.Time.Format(time.RFC3339),
 				Timestamp: flight.Departure.Time.Unix(),
-			},
-			Arrival: dto.Arrival{
+			}),
+			Arrival: providerutils.EnrichArrival(dto.Arrival{
 				Airport:   flight.Arrival.Airport,
 				City:      flight.Arrival.City,
+				Terminal:  flight.Arrival.Terminal,
 				Datetime:  flight.Arrival.Time.Format(time.RFC3339),
 				Timestamp: flight.Arrival.Time.Unix(),
-			},
+			}),
 			Duration: dto.Duration{
 				TotalMinutes: flight.DurationMinutes,
 				Formatted:    utils.ConvertMinutesToDuration(int64(flight.DurationMinutes)),
 			},
-			Stops: flight.Stops,
+			Stops: max(flight.Stops, len(layovers)),
 			Price: dto.Price{
 				Amount:    float64(flight.Price.Amount),
 				Currency:  flight.Price.Currency,
-				Formatted: utils.FormatRupiah(int64(flight.Price.Amount)),
+				Formatted: currency.Format(float64(flight.Price.Amount), flight.Price.Currency),
+				Breakdown: providerutils.TotalFare(float64(flight.Price.Amount)),
 			},
 			AvailableSeats: flight.AvailableSeats,
-			CabinClass:     flight.FareClass,
+			CabinClass:     providerutils.NormalizeCabinClass(flight.FareClass),
 			Aircraft:       &flight.Aircraft,
-			Amenities:      flight.Amenities,
+			Amenities:      providerutils.NormalizeAmenities(flight.Amenities, amenityMapping),
 			Baggage:        p.parseBaggage(flight.Baggage),
+			Segments:       segments,
+			Layovers:       layovers,
 		}
 	}
 	return results
 }
 
+// parseSegments normalizes the segments, the layover of a segment
+// is spent at its departure airport before it takes off
+func (p *Provider) parseSegments(segments []FlightSegment) ([]dto.Segment, []dto.Layover) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+
+	results := make([]dto.Segment, len(segments))
+	var layovers []dto.Layover
+	for i, segment := range segments {
+		results[i] = dto.Segment{
+			FlightNumber: segment.FlightNumber,
+			Departure: providerutils.EnrichDeparture(dto.Departure{
+				Airport:   segment.Departure.Airport,
+				City:      segment.Departure.City,
+				Terminal:  segment.Departure.Terminal,
+				Datetime:  segment.Departure.Time.Format(time.RFC3339),
+				Timestamp: segment.Departure.Time.Unix(),
+			}),
+			Arrival: providerutils.EnrichArrival(dto.Arrival{
+				Airport:   segment.Arrival.Airport,
+				City:      segment.Arrival.City,
+				Terminal:  segment.Arrival.Terminal,
+				Datetime:  segment.Arrival.Time.Format(time.RFC3339),
+				Timestamp: segment.Arrival.Time.Unix(),
+			}),
+			Duration: &dto.Duration{
+				TotalMinutes: segment.DurationMinutes,
+				Formatted:    utils.ConvertMinutesToDuration(int64(segment.DurationMinutes)),
+			},
+		}
+
+		if i > 0 {
+			layovers = append(layovers, providerutils.NewLayover(segment.Departure.Airport,
+				segment.LayoverMinutes))
+		}
+	}
+
+	return results, layovers
+}
+
 func (p *Provider) generateID(code, name string) string {
 	return fmt.Sprintf("%s_%s", code, name)
 }
 
+// parseBaggage keeps the piece counts, garuda doesn't give the weight
 func (p *Provider) parseBaggage(b Baggage) dto.Baggage {
 	return dto.Baggage{
-		CarryOn: fmt.Sprintf("%dkg", b.CarryOn*7),  // assuming 1 carry on = 7kg
-		Checked: fmt.Sprintf("%dkg", b.Checked*15), // assuming 1 checked bag = 15kg
+		CarryOn: providerutils.PiecesBaggage(b.CarryOn),
+		Checked: providerutils.PiecesBaggage(b.Checked),
 	}
 }
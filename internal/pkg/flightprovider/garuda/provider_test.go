@@ -0,0 +1,185 @@
+//go:build unit
+
+package garuda
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProvider_SearchRequest(t *testing.T) {
+	data, err := os.ReadFile("../../../../tests/mockprovider/garuda_indonesia_search_response.json")
+	assert.NoError(t, err)
+
+	var fixture struct {
+		Flights []map[string]any `json:"flights"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &fixture))
+
+	// GA400 departs from CGK, the same flight from HLP is added to the fixture
+	fromCGK := fixture.Flights[0]
+	fromHLP := map[string]any{}
+	for k, v := range fromCGK {
+		fromHLP[k] = v
+	}
+	fromHLP["flight_id"] = "GA402"
+	fromHLP["departure"] = map[string]any{
+		"airport": "HLP",
+		"city":    "Jakarta",
+		"time":    fromCGK["departure"].(map[string]any)["time"],
+	}
+
+	var origins, cabins string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origins, cabins = r.URL.Query().Get("origin"), r.URL.Query().Get("cabin_class")
+
+		// the airline matches every requested airport exactly
+		matched := []map[string]any{}
+		for _, flight := range []map[string]any{fromCGK, fromHLP} {
+			airport := flight["departure"].(map[string]any)["airport"].(string)
+			for _, origin := range strings.Split(origins, ",") {
+				if origin == airport {
+					matched = append(matched, flight)
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": "success", "flights": matched})
+	}))
+	defer server.Close()
+
+	transport, err := providerutils.NewTransport(providerutils.TransportConfig{
+		Mode: providerutils.ModeHTTP,
+		URL:  server.URL,
+	})
+	assert.NoError(t, err)
+
+	p := &Provider{Name: ProviderName, Transport: transport}
+
+	criteria := dto.SearchCriteria{
+		Origin:              "JKT",
+		Destination:         "DPS",
+		DepartureDate:       "2025-12-15",
+		Passengers:          1,
+		CabinClass:          dto.CabinEconomy,
+		IncludeHigherCabins: true,
+	}
+
+	body, err := p.Transport.Do(context.Background(), p.searchRequest(criteria))
+	assert.NoError(t, err)
+
+	var response SearchFlightResponse
+	assert.NoError(t, json.Unmarshal(body, &response))
+
+	flights := providerutils.FilterFlights(p.flightToDTO(response.Flights), criteria)
+
+	airports := []string{}
+	for _, f := range flights {
+		airports = append(airports, f.Departure.Airport)
+	}
+	sort.Strings(airports)
+
+	// a city code search reaches every airport of the city and every higher cabin
+	assert.Equal(t, []string{"CGK", "HLP"}, airports)
+	assert.Equal(t, "CGK,HLP", origins)
+	assert.Equal(t, "economy,premium_economy,business,first", cabins)
+}
+
+// routes lists every segment as its flight number, airports and duration
+func routes(segments []dto.Segment) []string {
+	if segments == nil {
+		return nil
+	}
+
+	results := make([]string, len(segments))
+	for i, s := range segments {
+		results[i] = s.FlightNumber + " " + s.Departure.Airport + "-" + s.Arrival.Airport + " " + s.Duration.Formatted
+	}
+
+	return results
+}
+
+func TestProvider_FlightToDTO_Segments_Closure(t *testing.T) {
+	data, err := os.ReadFile("../../../../tests/mockprovider/garuda_indonesia_search_response.json")
+	assert.NoError(t, err)
+
+	var response SearchFlightResponse
+	assert.NoError(t, json.Unmarshal(data, &response))
+
+	p := &Provider{Name: ProviderName}
+	flights := map[string]dto.Flight{}
+	for _, f := range p.flightToDTO(response.Flights) {
+		flights[f.FlightNumber] = f
+	}
+
+	mapFlight := func(f dto.Flight, wantStops int, wantRoutes []string, wantLayovers []dto.Layover) func(t *testing.T) {
+		return func(t *testing.T) {
+			assert.Equal(t, wantStops, f.Stops)
+			assert.Equal(t, wantRoutes, routes(f.Segments))
+			assert.Equal(t, wantLayovers, f.Layovers)
+		}
+	}
+
+	t.Run("direct", mapFlight(flights["GA400"], 0, nil, nil))
+
+	// the stops of the fixture are 0 but the segments connect in SUB
+	t.Run("connecting_segments", mapFlight(flights["GA315"], 1,
+		[]string{"GA315 CGK-SUB 1h 30m", "GA332 SUB-DPS 1h 30m"},
+		[]dto.Layover{
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 105, Formatted: "1h 45m"}},
+		},
+	))
+
+	wib, wita := time.FixedZone("WIB", 7*3600), time.FixedZone("WITA", 8*3600)
+	multiStop := p.flightToDTO([]Flight{{
+		FlightID:  "GA900",
+		Departure: FlightPoint{Airport: "KNO", Time: time.Date(2025, 12, 15, 6, 0, 0, 0, wib)},
+		Arrival:   FlightPoint{Airport: "DPS", Time: time.Date(2025, 12, 15, 15, 0, 0, 0, wita)},
+		Stops:     2,
+		Segments: []FlightSegment{
+			{FlightNumber: "GA900", DurationMinutes: 135,
+				Departure: FlightPoint{Airport: "KNO", Time: time.Date(2025, 12, 15, 6, 0, 0, 0, wib)},
+				Arrival:   FlightPoint{Airport: "CGK", Time: time.Date(2025, 12, 15, 8, 15, 0, 0, wib)}},
+			{FlightNumber: "GA310", DurationMinutes: 90, LayoverMinutes: 60,
+				Departure: FlightPoint{Airport: "CGK", Time: time.Date(2025, 12, 15, 9, 15, 0, 0, wib)},
+				Arrival:   FlightPoint{Airport: "SUB", Time: time.Date(2025, 12, 15, 10, 45, 0, 0, wib)}},
+			{FlightNumber: "GA332", DurationMinutes: 90, LayoverMinutes: 45,
+				Departure: FlightPoint{Airport: "SUB", Time: time.Date(2025, 12, 15, 11, 30, 0, 0, wib)},
+				Arrival:   FlightPoint{Airport: "DPS", Time: time.Date(2025, 12, 15, 15, 0, 0, 0, wita)}},
+		},
+	}})[0]
+
+	t.Run("multi_stop", mapFlight(multiStop, 2,
+		[]string{"GA900 KNO-CGK 2h 15m", "GA310 CGK-SUB 1h 30m", "GA332 SUB-DPS 1h 30m"},
+		[]dto.Layover{
+			{Airport: "CGK", City: "Jakarta", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}},
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 45, Formatted: "45m"}},
+		},
+	))
+}
+
+func TestAmenityMapping_Closure(t *testing.T) {
+	mapRequest := func(amenities []string, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			assert.Equal(t, want, providerutils.NormalizeAmenities(amenities, amenityMapping))
+		}
+	}
+
+	t.Run("power_outlet", mapRequest([]string{"power_outlet"}, []string{dto.AmenityPower}))
+	t.Run("usb_port", mapRequest([]string{"usb_port"}, []string{dto.AmenityUSB}))
+	t.Run("ife_and_entertainment", mapRequest([]string{"ife", "entertainment"}, []string{dto.AmenityEntertainment}))
+	t.Run("wifi_spellings", mapRequest([]string{"wi-fi", "wifi"}, []string{dto.AmenityWifi}))
+	t.Run("unknown_is_dropped", mapRequest([]string{"lounge", "meal"}, []string{dto.AmenityMeal}))
+}
@@ -0,0 +1,143 @@
+package flightprovider
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// HedgeConfig sends a duplicate search when the first one is slower than the Percentile
+// latency of the last Window successful searches, once MinSamples searches are recorded
+type HedgeConfig struct {
+	Percentile float64
+	MinSamples int
+	Window     int
+}
+
+// HedgedProvider hedges the searches of a provider, the first successful search wins
+// and the other one is cancelled
+type HedgedProvider struct {
+	Name     string
+	Provider FlightProvider
+
+	config    HedgeConfig
+	latencies *latencyWindow
+}
+
+type hedgeResult struct {
+	flights []dto.Flight
+	err     error
+}
+
+func NewHedgedProvider(name string, provider FlightProvider, config HedgeConfig) *HedgedProvider {
+	if config.Percentile <= 0 || config.Percentile > 1 {
+		config.Percentile = 0.95
+	}
+
+	if config.Window <= 0 {
+		config.Window = 100
+	}
+
+	return &HedgedProvider{
+		Name:      name,
+		Provider:  provider,
+		config:    config,
+		latencies: newLatencyWindow(config.Window),
+	}
+}
+
+func (h *HedgedProvider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
+	hedgeAfter, ok := h.latencies.percentile(h.config.Percentile, h.config.MinSamples)
+	if !ok {
+		return h.search(ctx, criteria)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	call := func() {
+		flights, err := h.search(ctx, criteria)
+		results <- hedgeResult{flights: flights, err: err}
+	}
+
+	go call()
+	inflight, hedged := 1, false
+
+	timer := time.NewTimer(hedgeAfter)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			slog.InfoContext(ctx, "hedging slow provider search", "provider", h.Name,
+				"hedge_after", hedgeAfter)
+			hedged = true
+			inflight++
+			go call()
+		case result := <-results:
+			inflight--
+			// a failed search only waits for the other one when it was hedged
+			if result.err == nil || !hedged || inflight == 0 {
+				return result.flights, result.err
+			}
+		}
+	}
+}
+
+// search records the latency of the successful searches
+func (h *HedgedProvider) search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
+	start := time.Now()
+	flights, err := h.Provider.Search(ctx, criteria)
+	if err == nil {
+		h.latencies.add(time.Since(start))
+	}
+
+	return flights, err
+}
+
+// latencyWindow keeps the last size latencies
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+	size    int
+}
+
+func newLatencyWindow(size int) *latencyWindow {
+	return &latencyWindow{samples: make([]time.Duration, 0, size), size: size}
+}
+
+func (w *latencyWindow) add(latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < w.size {
+		w.samples = append(w.samples, latency)
+		return
+	}
+
+	w.samples[w.next] = latency
+	w.next = (w.next + 1) % w.size
+}
+
+// percentile returns the latency at the percentile, false until there are minSamples
+func (w *latencyWindow) percentile(p float64, minSamples int) (time.Duration, bool) {
+	w.mu.Lock()
+	sorted := append([]time.Duration{}, w.samples...)
+	w.mu.Unlock()
+
+	if len(sorted) == 0 || len(sorted) < minSamples {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+
+	return sorted[max(index, 0)], true
+}
@@ -0,0 +1,115 @@
+//go:build unit
+
+package flightprovider
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// stubProvider answers the nth search with search
+type stubProvider struct {
+	calls  atomic.Int32
+	search func(ctx context.Context, n int32) ([]dto.Flight, error)
+}
+
+func (p *stubProvider) Search(ctx context.Context, _ dto.SearchCriteria) ([]dto.Flight, error) {
+	return p.search(ctx, p.calls.Add(1))
+}
+
+func TestHedgedProvider_Closure(t *testing.T) {
+	hangFirst := func(ctx context.Context, n int32) ([]dto.Flight, error) {
+		if n == 1 {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return []dto.Flight{{ID: "hedge"}}, nil
+	}
+
+	hedgeRequest := func(samples []time.Duration, search func(context.Context, int32) ([]dto.Flight, error),
+		wantIDs []string, wantCalls int32, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			provider := &stubProvider{search: search}
+			hedged := NewHedgedProvider("test-provider", provider, HedgeConfig{MinSamples: 2})
+			for _, sample := range samples {
+				hedged.latencies.add(sample)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			flights, err := hedged.Search(ctx, dto.SearchCriteria{})
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("HedgedProvider.Search expected error %v, got %v", wantErr, err)
+			}
+
+			gotIDs := []string{}
+			for _, f := range flights {
+				gotIDs = append(gotIDs, f.ID)
+			}
+
+			if diff := cmp.Diff(wantIDs, gotIDs); diff != "" {
+				t.Fatalf("HedgedProvider.Search flights mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantCalls, provider.calls.Load()); diff != "" {
+				t.Fatalf("HedgedProvider.Search calls mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	fast := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}
+
+	t.Run("hedge_wins_over_slow_search", hedgeRequest(fast, hangFirst, []string{"hedge"}, 2, nil))
+
+	t.Run("no_hedge_before_min_samples", hedgeRequest(nil,
+		func(ctx context.Context, n int32) ([]dto.Flight, error) {
+			time.Sleep(30 * time.Millisecond)
+			return []dto.Flight{{ID: "first"}}, nil
+		}, []string{"first"}, 1, nil))
+
+	t.Run("fast_search_is_not_hedged", hedgeRequest(fast,
+		func(ctx context.Context, n int32) ([]dto.Flight, error) {
+			return []dto.Flight{{ID: "first"}}, nil
+		}, []string{"first"}, 1, nil))
+
+	errFailed := errors.New("failed")
+	t.Run("failure_before_hedge_is_returned", hedgeRequest(fast,
+		func(ctx context.Context, n int32) ([]dto.Flight, error) {
+			return nil, errFailed
+		}, []string{}, 1, errFailed))
+}
+
+func TestLatencyWindow_Percentile_Closure(t *testing.T) {
+	percentileRequest := func(samples []time.Duration, size int, p float64, want time.Duration) func(t *testing.T) {
+		return func(t *testing.T) {
+			window := newLatencyWindow(size)
+			for _, sample := range samples {
+				window.add(sample)
+			}
+
+			got, _ := window.percentile(p, 1)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("latencyWindow.percentile mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	ms := func(values ...int) []time.Duration {
+		durations := make([]time.Duration, len(values))
+		for i, v := range values {
+			durations[i] = time.Duration(v) * time.Millisecond
+		}
+		return durations
+	}
+
+	t.Run("p95", percentileRequest(ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 100),
+		20, 0.95, 19*time.Millisecond))
+	t.Run("oldest_sample_is_dropped", percentileRequest(ms(100, 1, 2), 2, 1, 2*time.Millisecond))
+}
@@ -4,14 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log/slog"
-	"math/rand"
-	"os"
-	"strings"
+	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
@@ -24,120 +24,117 @@ const (
 
 type Provider struct {
 	Name         string
-	SearchAPIURL string
+	Transport    providerutils.Transport
 	Timeout      time.Duration
-	MaxRetries   int
+	Retry        *flightprovider.RetryPolicy
 	Limiter      *redis_rate.Limiter
 	RateLimitRPS int
 }
 
-func NewProvider(config flightprovider.FlightProviderConfig) *Provider {
+func NewProvider(config flightprovider.FlightProviderConfig) (*Provider, error) {
+	transport, err := providerutils.NewTransport(providerutils.TransportConfig{
+		Mode:             config.Mode,
+		URL:              config.SearchAPIURL,
+		Auth:             providerutils.APIKeyAuth("X-Api-Key", config.APIKey),
+		Client:           config.HTTPClient,
+		MaxResponseBytes: config.MaxResponseBytes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s transport: %w", ProviderName, err)
+	}
+
 	return &Provider{
 		Name:         ProviderName,
-		SearchAPIURL: config.SearchAPIURL,
+		Transport:    transport,
 		Timeout:      config.Timeout,
-		MaxRetries:   config.MaxRetries,
+		Retry:        flightprovider.NewRetryPolicy(ProviderName, config),
 		Limiter:      config.Limiter,
 		RateLimitRPS: config.RateLimitRPS,
-	}
+	}, nil
 }
 
-// Search will simulate API call to LionAir flight search API
-// it will return always success with delay 100-200ms
-func (p *Provider) Search(ctx context.Context,
-	criteria dto.SearchCriteria,
-) ([]dto.Flight, error) {
+// Search calls the LionAir flight search API, or reads the fixture file in file mode,
+// failed calls are retried with the retry policy of the provider
+func (p *Provider) Search(ctx context.Context, criteria dto.SearchCriteria) ([]dto.Flight, error) {
 	ctx, cancel := context.WithTimeout(ctx, p.Timeout)
 	defer cancel()
 
-	var lastErr error
-	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
-		// simulate delay 100-200ms
-		delay := time.Duration(100+rand.Intn(101)) * time.Millisecond
-		select {
-		case <-time.After(delay):
-		case <-ctx.Done():
-			return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
-		}
-
-		// always success not simulate error
-		if rand.Float64() < 0 {
-			lastErr = providerutils.ErrProviderInternalError
-			slog.ErrorContext(ctx, "failed to call lionair flight search API", "attempt",
-				attempt+1, "error", lastErr)
-
-			if attempt < p.MaxRetries {
-				// Exponential backoff: 200ms * 2^attempt
-				backoff := time.Duration(200*(1<<attempt)) * time.Millisecond
-				slog.InfoContext(ctx, "retrying with exponential backoff", "backoff",
-					backoff, "next_attempt", attempt+2)
-				select {
-				case <-time.After(backoff):
-					continue
-				case <-ctx.Done():
-					return nil, ctx.Err()
-				}
-			}
-			continue
-		}
-
+	var flightData []byte
+	err := p.Retry.Do(ctx, func(ctx context.Context) error {
 		// rate limit
 		res, err := p.Limiter.Allow(ctx, fmt.Sprintf("limit:%s", p.Name),
 			redis_rate.PerSecond(p.RateLimitRPS))
 		if err != nil {
-			return nil, fmt.Errorf("failed to rate limit: %w", err)
+			return fmt.Errorf("failed to rate limit: %w", err)
 		}
 
 		if res.Allowed == 0 {
-			return nil, providerutils.ErrProviderRateLimitExceeded
-		}
-
-		// get response from mock file
-		flightData, err := os.ReadFile(p.SearchAPIURL)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read mock file: %w", err)
+			return providerutils.ErrProviderRateLimitExceeded
 		}
 
-		var response SearchFlightResponse
-		if err := json.Unmarshal(flightData, &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal mock file: %w", err)
-		}
+		flightData, err = p.Transport.Do(ctx, p.searchRequest(criteria))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call lionair flight search API: %w", err)
+	}
 
-		// convert to dto.Flight and filter
-		flights := p.flightToDTO(response.Data.AvailableFlights)
-		return providerutils.FilterFlights(flights, criteria), nil
+	var response SearchFlightResponse
+	if err := json.Unmarshal(flightData, &response); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal flight search response: %w", err)
 	}
 
-	return nil, fmt.Errorf("failed to get flight data after %d attempts", p.MaxRetries)
+	// convert to dto.Flight and filter
+	flights := p.flightToDTO(response.Data.AvailableFlights)
+
+	return providerutils.FilterFlights(flights, criteria), nil
+}
+
+// searchRequest builds the LionAir flight search API request
+func (p *Provider) searchRequest(criteria dto.SearchCriteria) providerutils.Request {
+	params := providerutils.NewSearchParams(criteria)
+
+	return providerutils.Request{
+		Method: http.MethodGet,
+		Path:   "/api/flights/search",
+		Query: url.Values{
+			"from":  {params.Origin},
+			"to":    {params.Destination},
+			"date":  {criteria.DepartureDate},
+			"pax":   {strconv.Itoa(criteria.SeatCount())},
+			"class": {params.CabinClass},
+		},
+	}
 }
 
 func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 	results := make([]dto.Flight, len(flights))
 	for i, flight := range flights {
+		operatingAirline := providerutils.OperatingAirline(flight.Carrier.Name, flight.Carrier.IATA,
+			dto.Airline{Name: ProviderName, Code: ProviderCode})
 		deptTime := p.parseTimeWithLocation(flight.Schedule.Departure, flight.Schedule.DepartureTimezone)
 		arrTime := p.parseTimeWithLocation(flight.Schedule.Arrival, flight.Schedule.ArrivalTimezone)
 		amenities := p.getAmenities(flight.Services)
+		layovers := p.parseLayovers(flight.Layovers)
 
 		results[i] = dto.Flight{
-			ID:       p.generateID(flight.ID, p.Name),
-			Provider: p.Name,
-			Airline: dto.Airline{
-				Name: ProviderName,
-				Code: ProviderCode,
-			},
-			FlightNumber: flight.ID,
-			Departure: dto.Departure{
+			ID:               p.generateID(flight.ID, p.Name),
+			Provider:         p.Name,
+			Airline:          providerutils.MarketingAirline(flight.ID, operatingAirline),
+			OperatingAirline: operatingAirline,
+			FlightNumber:     flight.ID,
+			Departure: providerutils.EnrichDeparture(dto.Departure{
 				Airport:   flight.Route.From.Code,
 				City:      flight.Route.From.City,
 				Datetime:  deptTime.Format(time.RFC3339),
 				Timestamp: deptTime.Unix(),
-			},
-			Arrival: dto.Arrival{
+			}),
+			Arrival: providerutils.EnrichArrival(dto.Arrival{
 				Airport:   flight.Route.To.Code,
 				City:      flight.Route.To.City,
 				Datetime:  arrTime.Format(time.RFC3339),
 				Timestamp: arrTime.Unix(),
-			},
+			}),
 			Duration: dto.Duration{
 				TotalMinutes: flight.FlightTime,
 				Formatted:    utils.ConvertMinutesToDuration(int64(flight.FlightTime)),
@@ -146,21 +143,37 @@ func (p *Provider) flightToDTO(flights []Flight) []dto.Flight {
 			Price: dto.Price{
 				Amount:    float64(flight.Pricing.Total),
 				Currency:  flight.Pricing.Currency,
-				Formatted: utils.FormatRupiah(int64(flight.Pricing.Total)),
+				Formatted: currency.Format(float64(flight.Pricing.Total), flight.Pricing.Currency),
+				Breakdown: providerutils.TotalFare(float64(flight.Pricing.Total)),
 			},
 			AvailableSeats: flight.SeatsLeft,
-			CabinClass:     strings.ToLower(flight.Pricing.FareType),
+			CabinClass:     providerutils.NormalizeCabinClass(flight.Pricing.FareType),
 			Aircraft:       &flight.PlaneType,
 			Amenities:      amenities,
 			Baggage: dto.Baggage{
-				CarryOn: flight.Services.BaggageAllowance.Cabin,
-				Checked: flight.Services.BaggageAllowance.Hold,
+				CarryOn: providerutils.ParseBaggage(flight.Services.BaggageAllowance.Cabin),
+				Checked: providerutils.ParseBaggage(flight.Services.BaggageAllowance.Hold),
 			},
+			Layovers: layovers,
 		}
+		results[i].Segments = providerutils.BuildSegments(results[i], layovers)
 	}
 	return results
 }
 
+func (p *Provider) parseLayovers(layovers []Layover) []dto.Layover {
+	if len(layovers) == 0 {
+		return nil
+	}
+
+	results := make([]dto.Layover, len(layovers))
+	for i, layover := range layovers {
+		results[i] = providerutils.NewLayover(layover.Airport, layover.DurationMinutes)
+	}
+
+	return results
+}
+
 func (p *Provider) generateID(code, name string) string {
 	return fmt.Sprintf("%s_%s", code, name)
 }
@@ -177,10 +190,10 @@ func (p *Provider) parseTimeWithLocation(timeStr, locationStr string) time.Time
 func (p *Provider) getAmenities(services Services) []string {
 	amenities := []string{}
 	if services.WifiAvailable {
-		amenities = append(amenities, "wifi")
+		amenities = append(amenities, dto.AmenityWifi)
 	}
 	if services.MealsIncluded {
-		amenities = append(amenities, "meal")
+		amenities = append(amenities, dto.AmenityMeal)
 	}
 	return amenities
 }
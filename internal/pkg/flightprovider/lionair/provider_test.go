@@ -0,0 +1,127 @@
+//go:build unit
+
+package lionair
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// fixtureFlights maps the flights of the Lion Air fixture by flight number
+func fixtureFlights(t *testing.T) map[string]dto.Flight {
+	t.Helper()
+
+	data, err := os.ReadFile("../../../../tests/mockprovider/lion_air_search_response.json")
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+
+	var response SearchFlightResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		t.Fatalf("failed to decode fixture: %v", err)
+	}
+
+	p := &Provider{Name: ProviderName}
+	flights := map[string]dto.Flight{}
+	for _, f := range p.flightToDTO(response.Data.AvailableFlights) {
+		flights[f.FlightNumber] = f
+	}
+
+	return flights
+}
+
+// routes lists every segment as its flight number and airports
+func routes(segments []dto.Segment) []string {
+	if segments == nil {
+		return nil
+	}
+
+	results := make([]string, len(segments))
+	for i, s := range segments {
+		results[i] = s.FlightNumber + " " + s.Departure.Airport + "-" + s.Arrival.Airport
+	}
+
+	return results
+}
+
+func TestProvider_FlightToDTO_Segments_Closure(t *testing.T) {
+	mapFlight := func(f dto.Flight, wantStops int, wantRoutes []string, wantLayovers []dto.Layover) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(wantStops, f.Stops); diff != "" {
+				t.Fatalf("flightToDTO stops mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantRoutes, routes(f.Segments)); diff != "" {
+				t.Fatalf("flightToDTO segments mismatch (-want +got):\n%s", diff)
+			}
+
+			if diff := cmp.Diff(wantLayovers, f.Layovers); diff != "" {
+				t.Fatalf("flightToDTO layovers mismatch (-want +got):\n%s", diff)
+			}
+
+			// the first segment leaves like the flight and the last one lands like it
+			if len(f.Segments) > 0 {
+				if diff := cmp.Diff(f.Departure, f.Segments[0].Departure); diff != "" {
+					t.Fatalf("flightToDTO first segment departure mismatch (-want +got):\n%s", diff)
+				}
+
+				if diff := cmp.Diff(f.Arrival, f.Segments[len(f.Segments)-1].Arrival); diff != "" {
+					t.Fatalf("flightToDTO last segment arrival mismatch (-want +got):\n%s", diff)
+				}
+			}
+		}
+	}
+
+	flights := fixtureFlights(t)
+
+	t.Run("direct", mapFlight(flights["JT740"], 0, nil, nil))
+
+	t.Run("one_stop", mapFlight(flights["JT650"], 1,
+		[]string{"JT650 CGK-SUB", "JT650 SUB-DPS"},
+		[]dto.Layover{
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 75, Formatted: "1h 15m"}},
+		},
+	))
+
+	p := &Provider{Name: ProviderName}
+	multiStop := p.flightToDTO([]Flight{{
+		ID:        "JT900",
+		Carrier:   Carrier{Name: "Lion Air", IATA: "JT"},
+		Route:     Route{From: Airport{Code: "KNO"}, To: Airport{Code: "DPS"}},
+		Schedule:  Schedule{Departure: "2025-12-15T06:00:00", DepartureTimezone: "Asia/Jakarta", Arrival: "2025-12-15T15:00:00", ArrivalTimezone: "Asia/Makassar"},
+		StopCount: 2,
+		Layovers: []Layover{
+			{Airport: "CGK", DurationMinutes: 60},
+			{Airport: "SUB", DurationMinutes: 45},
+		},
+	}})[0]
+
+	t.Run("multi_stop", mapFlight(multiStop, 2,
+		[]string{"JT900 KNO-CGK", "JT900 CGK-SUB", "JT900 SUB-DPS"},
+		[]dto.Layover{
+			{Airport: "CGK", City: "Jakarta", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}},
+			{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 45, Formatted: "45m"}},
+		},
+	))
+}
+
+func TestProvider_GetAmenities_Closure(t *testing.T) {
+	p := &Provider{Name: ProviderName}
+
+	amenitiesRequest := func(services Services, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, p.getAmenities(services)); diff != "" {
+				t.Fatalf("getAmenities result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("wifi_and_meals", amenitiesRequest(Services{WifiAvailable: true, MealsIncluded: true},
+		[]string{dto.AmenityWifi, dto.AmenityMeal}))
+	t.Run("meals_only", amenitiesRequest(Services{MealsIncluded: true}, []string{dto.AmenityMeal}))
+	t.Run("none", amenitiesRequest(Services{}, []string{}))
+}
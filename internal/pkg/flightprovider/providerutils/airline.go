@@ -0,0 +1,83 @@
+package providerutils
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// AirlineNames maps IATA airline designators to the airline name
+var AirlineNames = map[string]string{
+	"GA": "Garuda Indonesia",
+	"QG": "Citilink",
+	"JT": "Lion Air",
+	"ID": "Batik Air",
+	"IW": "Wings Air",
+	"IU": "Super Air Jet",
+	"QZ": "Indonesia AirAsia",
+	"AK": "AirAsia",
+	"SJ": "Sriwijaya Air",
+	"IN": "NAM Air",
+	"8B": "TransNusa",
+	// the rest airline designators ...
+}
+
+// OperatingAirline is the carrier reported by the provider payload,
+// the provider's own airline is used when the payload leaves it empty
+func OperatingAirline(name, code string, fallback dto.Airline) dto.Airline {
+	airline := dto.Airline{Name: strings.TrimSpace(name), Code: strings.ToUpper(strings.TrimSpace(code))}
+	if airline.Code == "" {
+		airline.Code = fallback.Code
+	}
+
+	if airline.Name == "" {
+		airline.Name = fallback.Name
+		if airline.Code != fallback.Code {
+			airline.Name = AirlineName(airline.Code)
+		}
+	}
+
+	return airline
+}
+
+// MarketingAirline is the carrier selling the flight, taken from the airline designator
+// of the flight number, e.g. GA7310 operated by Citilink is marketed by GA.
+// the operating airline is used when the flight number has no designator
+func MarketingAirline(flightNumber string, operating dto.Airline) dto.Airline {
+	designator := airlineDesignator(flightNumber)
+	if designator == "" || designator == operating.Code {
+		return operating
+	}
+
+	return dto.Airline{
+		Name: AirlineName(designator),
+		Code: designator,
+	}
+}
+
+// AirlineName returns the name of the designator, the designator itself
+// when the airline isn't known so the carrier is never shown without a name
+func AirlineName(designator string) string {
+	if name, ok := AirlineNames[designator]; ok {
+		return name
+	}
+
+	return designator
+}
+
+// airlineDesignator returns the two character designator before the flight number digits,
+// designators may contain a digit such as 8B but never two
+func airlineDesignator(flightNumber string) string {
+	flightNumber = strings.ToUpper(strings.ReplaceAll(flightNumber, " ", ""))
+	if len(flightNumber) < 3 {
+		return ""
+	}
+
+	designator := flightNumber[:2]
+	if unicode.IsDigit(rune(designator[0])) && unicode.IsDigit(rune(designator[1])) {
+		return ""
+	}
+
+	return designator
+}
@@ -0,0 +1,64 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestAirlineDesignator_Closure(t *testing.T) {
+	designatorRequest := func(flightNumber, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, airlineDesignator(flightNumber)); diff != "" {
+				t.Fatalf("airlineDesignator(%q) mismatch (-want +got):\n%s", flightNumber, diff)
+			}
+		}
+	}
+
+	t.Run("letters", designatorRequest("GA400", "GA"))
+	t.Run("lower_case_with_space", designatorRequest("qz 7250", "QZ"))
+	t.Run("digit_and_letter", designatorRequest("8B123", "8B"))
+	t.Run("letter_and_digit", designatorRequest("G4123", "G4"))
+	t.Run("digits_only", designatorRequest("12345", ""))
+	t.Run("too_short", designatorRequest("GA", ""))
+	t.Run("empty", designatorRequest("", ""))
+}
+
+func TestOperatingAirline_Closure(t *testing.T) {
+	lionAir := dto.Airline{Name: "Lion Air", Code: "JT"}
+
+	operatingRequest := func(name, code string, want dto.Airline) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, OperatingAirline(name, code, lionAir)); diff != "" {
+				t.Fatalf("OperatingAirline(%q, %q) mismatch (-want +got):\n%s", name, code, diff)
+			}
+		}
+	}
+
+	t.Run("reported_carrier", operatingRequest("Wings Air", "iw", dto.Airline{Name: "Wings Air", Code: "IW"}))
+	t.Run("empty_payload_is_the_provider", operatingRequest("", "", lionAir))
+	t.Run("name_without_code", operatingRequest("Lion Air", "", lionAir))
+	t.Run("known_code_without_name", operatingRequest("", "IU", dto.Airline{Name: "Super Air Jet", Code: "IU"}))
+	t.Run("unknown_code_without_name", operatingRequest("", "XY", dto.Airline{Name: "XY", Code: "XY"}))
+}
+
+func TestMarketingAirline_Closure(t *testing.T) {
+	citilink := dto.Airline{Name: "Citilink", Code: "QG"}
+
+	marketingRequest := func(flightNumber string, operating, want dto.Airline) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, MarketingAirline(flightNumber, operating)); diff != "" {
+				t.Fatalf("MarketingAirline(%q) mismatch (-want +got):\n%s", flightNumber, diff)
+			}
+		}
+	}
+
+	t.Run("own_flight", marketingRequest("QG810", citilink, citilink))
+	t.Run("codeshare", marketingRequest("GA7310", citilink, dto.Airline{Name: "Garuda Indonesia", Code: "GA"}))
+	t.Run("unknown_designator_is_named_by_its_code", marketingRequest("XY123", citilink,
+		dto.Airline{Name: "XY", Code: "XY"}))
+	t.Run("no_designator", marketingRequest("7310", citilink, citilink))
+}
@@ -0,0 +1,32 @@
+package providerutils
+
+import (
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// NormalizeAmenities maps the amenities of a provider to the canonical vocabulary with
+// the provider mapping table, names already canonical are kept and unknown ones are dropped
+func NormalizeAmenities(amenities []string, mapping map[string]string) []string {
+	results := make([]string, 0, len(amenities))
+	seen := map[string]bool{}
+
+	for _, amenity := range amenities {
+		name := strings.ToLower(strings.TrimSpace(amenity))
+
+		canonical, ok := mapping[name]
+		if !ok && dto.IsAmenity(name) {
+			canonical, ok = name, true
+		}
+
+		if !ok || seen[canonical] {
+			continue
+		}
+
+		seen[canonical] = true
+		results = append(results, canonical)
+	}
+
+	return results
+}
@@ -0,0 +1,37 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestNormalizeAmenities_Closure(t *testing.T) {
+	mapping := map[string]string{
+		"power_outlet": dto.AmenityPower,
+		"wi-fi":        dto.AmenityWifi,
+		"ife":          dto.AmenityEntertainment,
+	}
+
+	normalizeRequest := func(amenities []string, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, NormalizeAmenities(amenities, mapping)); diff != "" {
+				t.Fatalf("NormalizeAmenities(%v) result mismatch (-want +got):\n%s", amenities, diff)
+			}
+		}
+	}
+
+	t.Run("mapped_alias", normalizeRequest([]string{"power_outlet"}, []string{dto.AmenityPower}))
+	t.Run("alias_ignores_case_and_space", normalizeRequest([]string{" Wi-Fi "}, []string{dto.AmenityWifi}))
+	t.Run("canonical_name_is_kept", normalizeRequest([]string{"meal", "USB"},
+		[]string{dto.AmenityMeal, dto.AmenityUSB}))
+	t.Run("unknown_is_dropped", normalizeRequest([]string{"spa", "wifi", "lounge"}, []string{dto.AmenityWifi}))
+	t.Run("duplicates_keep_the_first", normalizeRequest([]string{"wi-fi", "ife", "wifi", "entertainment"},
+		[]string{dto.AmenityWifi, dto.AmenityEntertainment}))
+	t.Run("provider_order_is_kept", normalizeRequest([]string{"ife", "meal", "power_outlet"},
+		[]string{dto.AmenityEntertainment, dto.AmenityMeal, dto.AmenityPower}))
+	t.Run("none", normalizeRequest(nil, []string{}))
+}
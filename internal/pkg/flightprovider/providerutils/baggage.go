@@ -0,0 +1,97 @@
+package providerutils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+var (
+	baggageWeight = regexp.MustCompile(`(\d+)\s*(?:kg|kgs|kilo)`)
+	baggagePieces = regexp.MustCompile(`(\d+)\s*(?:x|pc|pcs|piece|pieces|bag|bags)\b`)
+	// "2 x 23kg" gives the weight of each piece
+	baggagePerPiece = regexp.MustCompile(`(\d+)\s*x\s*(\d+)\s*(?:kg|kgs|kilo)`)
+	// words of baggage that has to be paid for
+	paidBaggage = []string{"fee", "paid", "purchase", "not included", "excluded"}
+	// words telling a part of a baggage note is about the cabin
+	carryOnBaggage = []string{"cabin", "carry", "hand"}
+)
+
+// ParseBaggage parses a free text allowance like "7 kg", "20kg checked",
+// "2 x 23kg" or "checked bags additional fee"
+func ParseBaggage(text string) dto.BaggageAllowance {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return dto.BaggageAllowance{}
+	}
+
+	lower := strings.ToLower(text)
+	allowance := dto.BaggageAllowance{
+		Included:    !containsAny(lower, paidBaggage),
+		Description: text,
+	}
+
+	if match := baggagePieces.FindStringSubmatch(lower); match != nil {
+		pieces, _ := strconv.Atoi(match[1])
+		allowance.Pieces = &pieces
+	}
+
+	if match := baggagePerPiece.FindStringSubmatch(lower); match != nil {
+		pieces, _ := strconv.Atoi(match[1])
+		weight, _ := strconv.Atoi(match[2])
+		weight *= pieces
+		allowance.WeightKg = &weight
+	} else if match := baggageWeight.FindStringSubmatch(lower); match != nil {
+		weight, _ := strconv.Atoi(match[1])
+		allowance.WeightKg = &weight
+	}
+
+	return allowance
+}
+
+// ParseBaggageNote splits a note covering both kinds of baggage, e.g.
+// "7kg cabin, 20kg checked", every part not about the cabin is checked baggage
+func ParseBaggageNote(note string) dto.Baggage {
+	var baggage dto.Baggage
+	for _, part := range strings.FieldsFunc(note, func(r rune) bool { return r == ',' || r == ';' }) {
+		if containsAny(strings.ToLower(part), carryOnBaggage) {
+			baggage.CarryOn = ParseBaggage(part)
+			continue
+		}
+
+		baggage.Checked = ParseBaggage(part)
+	}
+
+	return baggage
+}
+
+// PiecesBaggage is an allowance only given in pieces, the weight is left unknown
+func PiecesBaggage(pieces int) dto.BaggageAllowance {
+	if pieces <= 0 {
+		return dto.BaggageAllowance{}
+	}
+
+	unit := "pieces"
+	if pieces == 1 {
+		unit = "piece"
+	}
+
+	return dto.BaggageAllowance{
+		Included:    true,
+		Pieces:      &pieces,
+		Description: fmt.Sprintf("%d %s", pieces, unit),
+	}
+}
+
+func containsAny(text string, words []string) bool {
+	for _, word := range words {
+		if strings.Contains(text, word) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func intPtr(i int) *int { return &i }
+
+func TestParseBaggage_Closure(t *testing.T) {
+	parseRequest := func(text string, want dto.BaggageAllowance) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, ParseBaggage(text)); diff != "" {
+				t.Fatalf("ParseBaggage(%q) result mismatch (-want +got):\n%s", text, diff)
+			}
+		}
+	}
+
+	t.Run("weight", parseRequest("7 kg",
+		dto.BaggageAllowance{Included: true, WeightKg: intPtr(7), Description: "7 kg"}))
+	t.Run("weight_without_space", parseRequest("20kg checked",
+		dto.BaggageAllowance{Included: true, WeightKg: intPtr(20), Description: "20kg checked"}))
+	t.Run("pieces_times_weight_is_totalled", parseRequest("2 x 23kg",
+		dto.BaggageAllowance{Included: true, Pieces: intPtr(2), WeightKg: intPtr(46), Description: "2 x 23kg"}))
+	t.Run("pieces_only", parseRequest("1 piece",
+		dto.BaggageAllowance{Included: true, Pieces: intPtr(1), Description: "1 piece"}))
+	t.Run("paid_baggage_is_not_included", parseRequest("Cabin baggage only, checked bags additional fee",
+		dto.BaggageAllowance{Included: false, Description: "Cabin baggage only, checked bags additional fee"}))
+	t.Run("text_is_trimmed", parseRequest("  15 kg ",
+		dto.BaggageAllowance{Included: true, WeightKg: intPtr(15), Description: "15 kg"}))
+	t.Run("unparseable_keeps_description", parseRequest("Refer to fare rules",
+		dto.BaggageAllowance{Included: true, Description: "Refer to fare rules"}))
+	t.Run("empty", parseRequest("", dto.BaggageAllowance{}))
+	t.Run("blank", parseRequest("   ", dto.BaggageAllowance{}))
+}
+
+func TestParseBaggageNote_Closure(t *testing.T) {
+	parseRequest := func(note string, want dto.Baggage) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, ParseBaggageNote(note)); diff != "" {
+				t.Fatalf("ParseBaggageNote(%q) result mismatch (-want +got):\n%s", note, diff)
+			}
+		}
+	}
+
+	t.Run("cabin_and_checked", parseRequest("7kg cabin, 20kg checked", dto.Baggage{
+		CarryOn: dto.BaggageAllowance{Included: true, WeightKg: intPtr(7), Description: "7kg cabin"},
+		Checked: dto.BaggageAllowance{Included: true, WeightKg: intPtr(20), Description: "20kg checked"},
+	}))
+
+	t.Run("checked_for_a_fee", parseRequest("Cabin baggage only, checked bags additional fee", dto.Baggage{
+		CarryOn: dto.BaggageAllowance{Included: true, Description: "Cabin baggage only"},
+		Checked: dto.BaggageAllowance{Included: false, Description: "checked bags additional fee"},
+	}))
+
+	t.Run("semicolon_separated", parseRequest("Carry-on 7 kg; 2 x 20kg", dto.Baggage{
+		CarryOn: dto.BaggageAllowance{Included: true, WeightKg: intPtr(7), Description: "Carry-on 7 kg"},
+		Checked: dto.BaggageAllowance{Included: true, Pieces: intPtr(2), WeightKg: intPtr(40), Description: "2 x 20kg"},
+	}))
+
+	t.Run("empty", parseRequest("", dto.Baggage{}))
+}
+
+func TestPiecesBaggage_Closure(t *testing.T) {
+	piecesRequest := func(pieces int, want dto.BaggageAllowance) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, PiecesBaggage(pieces)); diff != "" {
+				t.Fatalf("PiecesBaggage(%d) result mismatch (-want +got):\n%s", pieces, diff)
+			}
+		}
+	}
+
+	t.Run("one_piece", piecesRequest(1,
+		dto.BaggageAllowance{Included: true, Pieces: intPtr(1), Description: "1 piece"}))
+	t.Run("pieces", piecesRequest(2,
+		dto.BaggageAllowance{Included: true, Pieces: intPtr(2), Description: "2 pieces"}))
+	t.Run("none", piecesRequest(0, dto.BaggageAllowance{}))
+}
@@ -0,0 +1,45 @@
+package providerutils
+
+import (
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// bookingClasses maps the common one-letter booking class codes to a cabin
+var bookingClasses = map[string]string{
+	"y": dto.CabinEconomy,
+	"w": dto.CabinPremiumEconomy,
+	"c": dto.CabinBusiness,
+	"j": dto.CabinBusiness,
+	"f": dto.CabinFirst,
+}
+
+// NormalizeCabinClass maps the cabin names of the providers, e.g. "PREMIUM ECONOMY",
+// "premium-economy" or "W", to the cabin classes of the search.
+// unknown cabins are returned normalized so they never match a search by accident
+func NormalizeCabinClass(cabin string) string {
+	normalized := strings.ToLower(strings.TrimSpace(cabin))
+	normalized = strings.NewReplacer(" ", "_", "-", "_").Replace(normalized)
+
+	if _, ok := dto.CabinRank[normalized]; ok {
+		return normalized
+	}
+
+	if class, ok := bookingClasses[normalized]; ok {
+		return class
+	}
+
+	switch {
+	case strings.Contains(normalized, "premium"):
+		return dto.CabinPremiumEconomy
+	case strings.Contains(normalized, "business"):
+		return dto.CabinBusiness
+	case strings.Contains(normalized, "first"):
+		return dto.CabinFirst
+	case strings.Contains(normalized, "economy"):
+		return dto.CabinEconomy
+	}
+
+	return normalized
+}
@@ -0,0 +1,69 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestNormalizeCabinClass_Closure(t *testing.T) {
+	normalize := func(cabin, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, NormalizeCabinClass(cabin)); diff != "" {
+				t.Fatalf("NormalizeCabinClass(%q) mismatch (-want +got):\n%s", cabin, diff)
+			}
+		}
+	}
+
+	t.Run("search_cabin", normalize("premium_economy", dto.CabinPremiumEconomy))
+	t.Run("upper_case_name", normalize("PREMIUM ECONOMY", dto.CabinPremiumEconomy))
+	t.Run("hyphenated_name", normalize("premium-economy", dto.CabinPremiumEconomy))
+	t.Run("padded_name", normalize(" Business ", dto.CabinBusiness))
+	t.Run("fare_name", normalize("Economy Saver", dto.CabinEconomy))
+	t.Run("first_class", normalize("First Class", dto.CabinFirst))
+	t.Run("booking_class_y", normalize("Y", dto.CabinEconomy))
+	t.Run("booking_class_w", normalize("w", dto.CabinPremiumEconomy))
+	t.Run("booking_class_c", normalize("C", dto.CabinBusiness))
+	t.Run("booking_class_j", normalize("J", dto.CabinBusiness))
+	t.Run("booking_class_f", normalize("F", dto.CabinFirst))
+	t.Run("unknown_booking_class", normalize("Q", "q"))
+	t.Run("unknown_cabin", normalize("Sleeper Suite", "sleeper_suite"))
+}
+
+func TestFilterFlights_Cabin_Closure(t *testing.T) {
+	flights := []dto.Flight{
+		{FlightNumber: "GA400", CabinClass: NormalizeCabinClass("economy")},
+		{FlightNumber: "ID6514", CabinClass: NormalizeCabinClass("W")},
+		{FlightNumber: "QZ520", CabinClass: NormalizeCabinClass("BUSINESS")},
+		{FlightNumber: "JT740", CabinClass: NormalizeCabinClass("First Class")},
+		{FlightNumber: "ID7042", CabinClass: NormalizeCabinClass("Q")},
+	}
+
+	filter := func(criteria dto.SearchCriteria, want []string) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := []string{}
+			for _, f := range FilterFlights(flights, criteria) {
+				got = append(got, f.FlightNumber)
+			}
+
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("FilterFlights result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("any_cabin", filter(dto.SearchCriteria{},
+		[]string{"GA400", "ID6514", "QZ520", "JT740", "ID7042"}))
+	t.Run("exact_cabin", filter(dto.SearchCriteria{CabinClass: dto.CabinPremiumEconomy},
+		[]string{"ID6514"}))
+	t.Run("include_higher_cabins", filter(
+		dto.SearchCriteria{CabinClass: dto.CabinPremiumEconomy, IncludeHigherCabins: true},
+		[]string{"ID6514", "QZ520", "JT740"}))
+	// an unknown cabin is never taken for a higher one
+	t.Run("economy_and_higher", filter(
+		dto.SearchCriteria{CabinClass: dto.CabinEconomy, IncludeHigherCabins: true},
+		[]string{"GA400", "ID6514", "QZ520", "JT740"}))
+}
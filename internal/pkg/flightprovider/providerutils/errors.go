@@ -20,3 +20,23 @@ var ErrProviderRateLimitExceeded = exception.ApplicationError{
 	StatusCode: http.StatusTooManyRequests,
 	Message:    "provider rate limit exceeded",
 }
+
+var ErrProviderUnauthorized = exception.ApplicationError{
+	StatusCode: http.StatusBadGateway,
+	Message:    "provider rejected the credentials",
+}
+
+var ErrProviderBadRequest = exception.ApplicationError{
+	StatusCode: http.StatusBadGateway,
+	Message:    "provider rejected the request",
+}
+
+var ErrProviderResponseTooLarge = exception.ApplicationError{
+	StatusCode: http.StatusBadGateway,
+	Message:    "provider response too large",
+}
+
+var ErrCircuitOpen = exception.ApplicationError{
+	StatusCode: http.StatusServiceUnavailable,
+	Message:    "provider circuit breaker is open",
+}
@@ -0,0 +1,24 @@
+package providerutils
+
+import (
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// ReportedFare builds the breakdown reported by a provider
+func ReportedFare(baseFare, taxes, surcharges, serviceFees float64) *dto.FareBreakdown {
+	return &dto.FareBreakdown{
+		BaseFare:    baseFare,
+		Taxes:       taxes,
+		Surcharges:  surcharges,
+		ServiceFees: serviceFees,
+		Reported:    true,
+	}
+}
+
+// TotalFare is the breakdown of a provider that only gives a total, the taxes and fees
+// aren't known so the whole total is the base fare and the breakdown is not reported
+func TotalFare(total float64) *dto.FareBreakdown {
+	return &dto.FareBreakdown{
+		BaseFare: total,
+	}
+}
@@ -6,15 +6,19 @@ import (
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
 )
 
+// FilterFlights keeps the flights matching the search criteria,
+// origin and destination city codes match every airport of the city
 func FilterFlights(flights []dto.Flight, criteria dto.SearchCriteria) []dto.Flight {
 	results := make([]dto.Flight, 0, len(flights))
+	origins := toSet(criteria.OriginAirports())
+	destinations := toSet(criteria.DestinationAirports())
 
 	for _, flight := range flights {
-		if criteria.Origin != "" && flight.Departure.Airport != criteria.Origin {
+		if criteria.Origin != "" && !origins[flight.Departure.Airport] {
 			continue
 		}
 
-		if criteria.Destination != "" && flight.Arrival.Airport != criteria.Destination {
+		if criteria.Destination != "" && !destinations[flight.Arrival.Airport] {
 			continue
 		}
 
@@ -25,11 +29,12 @@ func FilterFlights(flights []dto.Flight, criteria dto.SearchCriteria) []dto.Flig
 			}
 		}
 
-		if criteria.CabinClass != "" && flight.CabinClass != criteria.CabinClass {
+		if !criteria.MatchCabin(flight.CabinClass) {
 			continue
 		}
 
-		if criteria.Passengers != 0 && flight.AvailableSeats < criteria.Passengers {
+		// infants on lap don't need a seat
+		if seats := criteria.SeatCount(); seats != 0 && flight.AvailableSeats < seats {
 			continue
 		}
 
@@ -38,3 +43,12 @@ func FilterFlights(flights []dto.Flight, criteria dto.SearchCriteria) []dto.Flig
 
 	return results
 }
+
+func toSet(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[code] = true
+	}
+
+	return set
+}
@@ -0,0 +1,27 @@
+package providerutils
+
+import (
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+// ListSeparator joins the airports and cabins of one search request
+const ListSeparator = ","
+
+// SearchParams are the airports and cabins a provider is searched for. origin and destination
+// are every airport of the city and the nearby airports of the search, and the cabin class is
+// every cabin the search matches, so one call per provider searches all of them
+type SearchParams struct {
+	Origin      string
+	Destination string
+	CabinClass  string
+}
+
+func NewSearchParams(criteria dto.SearchCriteria) SearchParams {
+	return SearchParams{
+		Origin:      strings.Join(criteria.OriginAirports(), ListSeparator),
+		Destination: strings.Join(criteria.DestinationAirports(), ListSeparator),
+		CabinClass:  strings.Join(criteria.SearchCabins(), ListSeparator),
+	}
+}
@@ -0,0 +1,45 @@
+package providerutils
+
+import (
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/utils"
+)
+
+// NewLayover normalizes a layover reported by a provider
+func NewLayover(airport string, minutes int) dto.Layover {
+	return dto.Layover{
+		Airport: airport,
+		City:    CityOf(airport),
+		Duration: dto.Duration{
+			TotalMinutes: minutes,
+			Formatted:    utils.ConvertMinutesToDuration(int64(minutes)),
+		},
+	}
+}
+
+// BuildSegments chains the flight through its layover airports for providers
+// that report layovers without segments, every segment keeps the marketing flight number
+func BuildSegments(flight dto.Flight, layovers []dto.Layover) []dto.Segment {
+	if len(layovers) == 0 {
+		return nil
+	}
+
+	segments := make([]dto.Segment, 0, len(layovers)+1)
+	departure := flight.Departure
+	for _, layover := range layovers {
+		segments = append(segments, dto.Segment{
+			FlightNumber: flight.FlightNumber,
+			Departure:    departure,
+			Arrival:      EnrichArrival(dto.Arrival{Airport: layover.Airport}),
+		})
+		departure = EnrichDeparture(dto.Departure{Airport: layover.Airport})
+	}
+
+	segments = append(segments, dto.Segment{
+		FlightNumber: flight.FlightNumber,
+		Departure:    departure,
+		Arrival:      flight.Arrival,
+	})
+
+	return segments
+}
@@ -0,0 +1,56 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+)
+
+func TestBuildSegments_Closure(t *testing.T) {
+	flight := dto.Flight{
+		FlightNumber: "JT900",
+		Departure:    EnrichDeparture(dto.Departure{Airport: "KNO", Timestamp: 1000}),
+		Arrival:      EnrichArrival(dto.Arrival{Airport: "DPS", Timestamp: 2000}),
+	}
+
+	buildRequest := func(layovers []dto.Layover, want []dto.Segment) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := BuildSegments(flight, layovers)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("BuildSegments result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("direct_has_no_segments", buildRequest(nil, nil))
+
+	t.Run("chains_every_layover", buildRequest(
+		[]dto.Layover{NewLayover("CGK", 60), NewLayover("SUB", 45)},
+		[]dto.Segment{
+			{FlightNumber: "JT900", Departure: flight.Departure, Arrival: EnrichArrival(dto.Arrival{Airport: "CGK"})},
+			{FlightNumber: "JT900", Departure: EnrichDeparture(dto.Departure{Airport: "CGK"}),
+				Arrival: EnrichArrival(dto.Arrival{Airport: "SUB"})},
+			{FlightNumber: "JT900", Departure: EnrichDeparture(dto.Departure{Airport: "SUB"}), Arrival: flight.Arrival},
+		},
+	))
+}
+
+func TestNewLayover_Closure(t *testing.T) {
+	newLayover := func(airport string, minutes int, want dto.Layover) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, NewLayover(airport, minutes)); diff != "" {
+				t.Fatalf("NewLayover result mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("known_airport", newLayover("UPG", 55,
+		dto.Layover{Airport: "UPG", City: "Makassar", Duration: dto.Duration{TotalMinutes: 55, Formatted: "55m"}}))
+	t.Run("hours_and_minutes", newLayover("SUB", 105,
+		dto.Layover{Airport: "SUB", City: "Surabaya", Duration: dto.Duration{TotalMinutes: 105, Formatted: "1h 45m"}}))
+	t.Run("unknown_airport_has_no_city", newLayover("XXX", 60,
+		dto.Layover{Airport: "XXX", Duration: dto.Duration{TotalMinutes: 60, Formatted: "1h"}}))
+}
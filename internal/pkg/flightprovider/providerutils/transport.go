@@ -0,0 +1,261 @@
+package providerutils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// modes of the provider transport, file reads SearchAPIURL as a fixture file
+// and http calls SearchAPIURL as the base url of the provider API, e.g. cmd/mockairlines
+const (
+	ModeFile = "file"
+	ModeHTTP = "http"
+)
+
+// DefaultMaxResponseBytes limits the provider response when no limit is configured
+const DefaultMaxResponseBytes int64 = 5 << 20
+
+// Request is a provider API call, Path and Query are appended to the base url
+// and Body is sent as JSON when it is set
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Body   any
+}
+
+// Transport returns the raw response body of a provider API call
+type Transport interface {
+	Do(ctx context.Context, request Request) ([]byte, error)
+}
+
+// Auth sets the credentials of the provider on the http request
+type Auth func(r *http.Request)
+
+// APIKeyAuth sends the key in the header
+func APIKeyAuth(header, key string) Auth {
+	return func(r *http.Request) {
+		if key != "" {
+			r.Header.Set(header, key)
+		}
+	}
+}
+
+// BearerAuth sends the token as a bearer authorization
+func BearerAuth(token string) Auth {
+	return func(r *http.Request) {
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+}
+
+type TransportConfig struct {
+	Mode             string
+	URL              string
+	Auth             Auth
+	Client           *http.Client
+	MaxResponseBytes int64
+}
+
+// ErrUnknownMode is returned for a provider mode other than file or http
+var ErrUnknownMode = errors.New("unknown provider mode")
+
+// ValidateMode checks the mode of a provider, an empty mode is the file mode
+func ValidateMode(mode string) error {
+	switch strings.ToLower(mode) {
+	case ModeHTTP, ModeFile, "":
+		return nil
+	}
+
+	return fmt.Errorf("%w %q", ErrUnknownMode, mode)
+}
+
+// NewTransport returns the transport of the mode, an empty mode is the file mode
+func NewTransport(config TransportConfig) (Transport, error) {
+	if err := ValidateMode(config.Mode); err != nil {
+		return nil, err
+	}
+
+	maxResponseBytes := config.MaxResponseBytes
+	if maxResponseBytes <= 0 {
+		maxResponseBytes = DefaultMaxResponseBytes
+	}
+
+	switch strings.ToLower(config.Mode) {
+	case ModeHTTP:
+		client := config.Client
+		if client == nil {
+			client = NewHTTPClient(0)
+		}
+
+		return &HTTPTransport{
+			BaseURL:          strings.TrimRight(config.URL, "/"),
+			Client:           client,
+			Auth:             config.Auth,
+			MaxResponseBytes: maxResponseBytes,
+		}, nil
+	}
+
+	return &FileTransport{
+		Path:             config.URL,
+		MaxResponseBytes: maxResponseBytes,
+	}, nil
+}
+
+// NewHTTPClient returns a client pooling the connections to the providers,
+// timeouts are left to the request context
+func NewHTTPClient(maxIdleConnsPerHost int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.IdleConnTimeout = 90 * time.Second
+	if maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	}
+
+	return &http.Client{Transport: transport}
+}
+
+// HTTPTransport calls the provider API
+type HTTPTransport struct {
+	BaseURL          string
+	Client           *http.Client
+	Auth             Auth
+	MaxResponseBytes int64
+}
+
+func (t *HTTPTransport) Do(ctx context.Context, request Request) ([]byte, error) {
+	httpRequest, err := t.newRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := t.Client.Do(httpRequest)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("context cancelled or timeout: %w", ctx.Err())
+		}
+
+		// network errors are temporary so they are retried like a server error
+		return nil, fmt.Errorf("failed to call provider: %w: %w", ErrProviderInternalError, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := readLimited(resp.Body, t.MaxResponseBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := StatusError(resp.StatusCode); err != nil {
+		return nil, fmt.Errorf("provider responded %d: %w", resp.StatusCode, err)
+	}
+
+	return body, nil
+}
+
+func (t *HTTPTransport) newRequest(ctx context.Context, request Request) (*http.Request, error) {
+	method := request.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target := t.BaseURL + request.Path
+	if len(request.Query) > 0 {
+		target += "?" + request.Query.Encode()
+	}
+
+	var body io.Reader
+	if request.Body != nil {
+		payload, err := json.Marshal(request.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal provider request: %w", err)
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build provider request: %w", err)
+	}
+
+	httpRequest.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpRequest.Header.Set("Content-Type", "application/json")
+	}
+
+	if t.Auth != nil {
+		t.Auth(httpRequest)
+	}
+
+	return httpRequest, nil
+}
+
+// StatusError maps the status code of the provider to a provider error,
+// timeouts and server errors map to ErrProviderInternalError so they can be retried
+func StatusError(statusCode int) error {
+	switch {
+	case statusCode >= 200 && statusCode < 300:
+		return nil
+	case statusCode == http.StatusTooManyRequests:
+		return ErrProviderRateLimitExceeded
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return ErrProviderUnauthorized
+	case statusCode == http.StatusRequestTimeout || statusCode >= 500:
+		return ErrProviderInternalError
+	}
+
+	return ErrProviderBadRequest
+}
+
+// IsRetryable reports whether the provider call may succeed when it is tried again
+func IsRetryable(err error) bool {
+	return errors.Is(err, ErrProviderInternalError)
+}
+
+// FileTransport reads the provider response from a fixture file,
+// latency and failures are simulated by cmd/mockairlines in http mode
+type FileTransport struct {
+	Path             string
+	MaxResponseBytes int64
+}
+
+func (t *FileTransport) Do(ctx context.Context, _ Request) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("context cancelled or timeout: %w", err)
+	}
+
+	file, err := os.Open(t.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock file: %w", err)
+	}
+	defer file.Close()
+
+	return readLimited(file, t.MaxResponseBytes)
+}
+
+// readLimited reads up to limit bytes and fails when there is more
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		limit = DefaultMaxResponseBytes
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider response: %w", err)
+	}
+
+	if int64(len(body)) > limit {
+		return nil, ErrProviderResponseTooLarge
+	}
+
+	return body, nil
+}
@@ -0,0 +1,254 @@
+//go:build unit
+
+package providerutils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStatusError_Closure(t *testing.T) {
+	mapStatus := func(statusCode int, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			err := StatusError(statusCode)
+			if wantErr == nil && err != nil {
+				t.Fatalf("StatusError(%d) unexpected error: %v", statusCode, err)
+			}
+
+			if !errors.Is(err, wantErr) {
+				t.Fatalf("StatusError(%d) error %v doesn't wrap %v", statusCode, err, wantErr)
+			}
+		}
+	}
+
+	t.Run("ok", mapStatus(http.StatusOK, nil))
+	t.Run("no_content", mapStatus(http.StatusNoContent, nil))
+	t.Run("too_many_requests", mapStatus(http.StatusTooManyRequests, ErrProviderRateLimitExceeded))
+	t.Run("unauthorized", mapStatus(http.StatusUnauthorized, ErrProviderUnauthorized))
+	t.Run("forbidden", mapStatus(http.StatusForbidden, ErrProviderUnauthorized))
+	t.Run("request_timeout", mapStatus(http.StatusRequestTimeout, ErrProviderInternalError))
+	t.Run("internal_server_error", mapStatus(http.StatusInternalServerError, ErrProviderInternalError))
+	t.Run("bad_gateway", mapStatus(http.StatusBadGateway, ErrProviderInternalError))
+	t.Run("bad_request", mapStatus(http.StatusBadRequest, ErrProviderBadRequest))
+	t.Run("not_found", mapStatus(http.StatusNotFound, ErrProviderBadRequest))
+}
+
+func TestIsRetryable_Closure(t *testing.T) {
+	retryable := func(err error, want bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, IsRetryable(err)); diff != "" {
+				t.Fatalf("IsRetryable(%v) mismatch (-want +got):\n%s", err, diff)
+			}
+		}
+	}
+
+	t.Run("internal_error", retryable(ErrProviderInternalError, true))
+	t.Run("wrapped_internal_error", retryable(
+		fmt.Errorf("provider responded 503: %w", ErrProviderInternalError), true))
+	t.Run("rate_limit", retryable(ErrProviderRateLimitExceeded, false))
+	t.Run("unauthorized", retryable(ErrProviderUnauthorized, false))
+	t.Run("bad_request", retryable(ErrProviderBadRequest, false))
+	t.Run("too_large", retryable(ErrProviderResponseTooLarge, false))
+	t.Run("nil", retryable(nil, false))
+}
+
+func TestReadLimited_Closure(t *testing.T) {
+	read := func(body string, limit int64, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := readLimited(strings.NewReader(body), limit)
+			if wantErr != nil {
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("readLimited error %v doesn't wrap %v", err, wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("readLimited unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(body, string(got)); diff != "" {
+				t.Fatalf("readLimited body mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("under_limit", read("{}", 4, nil))
+	t.Run("at_limit", read("{\"a\"}", 5, nil))
+	t.Run("over_limit", read("{\"a\":1}", 5, ErrProviderResponseTooLarge))
+	t.Run("no_limit_uses_default", read("{}", 0, nil))
+}
+
+func TestHTTPTransport_Do_Closure(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/large":
+			_, _ = w.Write([]byte(strings.Repeat("x", 64)))
+		default:
+			_, _ = w.Write([]byte(`{"status":"success"}`))
+		}
+	}))
+	defer server.Close()
+
+	doRequest := func(auth Auth, request Request, wantHeaders map[string]string,
+		wantBody string, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			transport, err := NewTransport(TransportConfig{
+				Mode:             ModeHTTP,
+				URL:              server.URL + "/",
+				Auth:             auth,
+				Client:           server.Client(),
+				MaxResponseBytes: 32,
+			})
+			if err != nil {
+				t.Fatalf("NewTransport unexpected error: %v", err)
+			}
+
+			body, err := transport.Do(context.Background(), request)
+			if wantErr != nil {
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("HTTPTransport.Do error %v doesn't wrap %v", err, wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("HTTPTransport.Do unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(wantBody, string(body)); diff != "" {
+				t.Fatalf("HTTPTransport.Do body mismatch (-want +got):\n%s", diff)
+			}
+
+			for header, want := range wantHeaders {
+				if diff := cmp.Diff(want, got.Header.Get(header)); diff != "" {
+					t.Fatalf("HTTPTransport.Do header %s mismatch (-want +got):\n%s", header, diff)
+				}
+			}
+		}
+	}
+
+	t.Run("api_key_header", doRequest(
+		APIKeyAuth("X-Api-Key", "secret"),
+		Request{Path: "/search", Query: url.Values{"origin": {"CGK"}}},
+		map[string]string{"X-Api-Key": "secret", "Authorization": "", "Accept": "application/json"},
+		`{"status":"success"}`, nil,
+	))
+
+	t.Run("bearer_header", doRequest(
+		BearerAuth("token"),
+		Request{Method: http.MethodPost, Path: "/search", Body: map[string]string{"from": "CGK"}},
+		map[string]string{"Authorization": "Bearer token", "Content-Type": "application/json"},
+		`{"status":"success"}`, nil,
+	))
+
+	t.Run("empty_credentials_send_no_header", doRequest(
+		BearerAuth(""),
+		Request{Path: "/search"},
+		map[string]string{"Authorization": ""},
+		`{"status":"success"}`, nil,
+	))
+
+	t.Run("server_error_is_retryable", doRequest(
+		nil, Request{Path: "/fail"}, nil, "", ErrProviderInternalError,
+	))
+
+	t.Run("response_over_limit", doRequest(
+		nil, Request{Path: "/large"}, nil, "", ErrProviderResponseTooLarge,
+	))
+
+	t.Run("query_is_encoded", func(t *testing.T) {
+		doRequest(nil, Request{Path: "/search", Query: url.Values{"origin": {"CGK,HLP"}}},
+			nil, `{"status":"success"}`, nil)(t)
+
+		if diff := cmp.Diff("CGK,HLP", got.URL.Query().Get("origin")); diff != "" {
+			t.Fatalf("HTTPTransport.Do query mismatch (-want +got):\n%s", diff)
+		}
+	})
+}
+
+func TestFileTransport_Do_Closure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "response.json")
+	if err := os.WriteFile(path, []byte(`{"flights":[]}`), 0o600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	readFile := func(ctx context.Context, path string, limit int64, wantBody string,
+		wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			transport, err := NewTransport(TransportConfig{Mode: ModeFile, URL: path,
+				MaxResponseBytes: limit})
+			if err != nil {
+				t.Fatalf("NewTransport unexpected error: %v", err)
+			}
+
+			body, err := transport.Do(ctx, Request{})
+			if wantErr != nil {
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("FileTransport.Do error %v doesn't wrap %v", err, wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("FileTransport.Do unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(wantBody, string(body)); diff != "" {
+				t.Fatalf("FileTransport.Do body mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("reads_fixture", readFile(context.Background(), path, 0, `{"flights":[]}`, nil))
+	t.Run("fixture_over_limit", readFile(context.Background(), path, 4, "",
+		ErrProviderResponseTooLarge))
+	t.Run("missing_fixture", readFile(context.Background(), filepath.Join(dir, "missing.json"),
+		0, "", os.ErrNotExist))
+	t.Run("cancelled_context", readFile(cancelled, path, 0, "", context.Canceled))
+}
+
+func TestNewTransport_Closure(t *testing.T) {
+	newTransport := func(mode string, want Transport, wantErr error) func(t *testing.T) {
+		return func(t *testing.T) {
+			got, err := NewTransport(TransportConfig{Mode: mode, URL: "http://localhost/"})
+			if wantErr != nil {
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("NewTransport error %v doesn't wrap %v", err, wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewTransport unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(fmt.Sprintf("%T", want), fmt.Sprintf("%T", got)); diff != "" {
+				t.Fatalf("NewTransport type mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("empty_mode_is_file", newTransport("", &FileTransport{}, nil))
+	t.Run("file_mode", newTransport(ModeFile, &FileTransport{}, nil))
+	t.Run("http_mode_ignores_case", newTransport("HTTP", &HTTPTransport{}, nil))
+	t.Run("unknown_mode", newTransport("grpc", nil, ErrUnknownMode))
+}
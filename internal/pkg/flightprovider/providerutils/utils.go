@@ -1,10 +1,50 @@
 package providerutils
 
+import (
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/airport"
+)
+
 var CurrencyIDR = "IDR"
 
-var MapAirportToCity = map[string]string{
-	"CGK": "Jakarta",
-	"DPS": "Denpasar",
-	"SUB": "Surabaya",
-	// the rest airport codes ...
+// CityOf returns the city of an airport code, empty when the airport is unknown
+func CityOf(code string) string {
+	a, _ := airport.Default.Lookup(code)
+	return a.City
+}
+
+// EnrichDeparture fills the airport details from the airport reference data,
+// the city reported by the provider is kept
+func EnrichDeparture(departure dto.Departure) dto.Departure {
+	a, ok := airport.Default.Lookup(departure.Airport)
+	if !ok {
+		return departure
+	}
+
+	if departure.City == "" {
+		departure.City = a.City
+	}
+	departure.AirportName = a.Name
+	departure.Country = a.Country
+	departure.Timezone = a.Timezone
+
+	return departure
+}
+
+// EnrichArrival fills the airport details from the airport reference data,
+// the city reported by the provider is kept
+func EnrichArrival(arrival dto.Arrival) dto.Arrival {
+	a, ok := airport.Default.Lookup(arrival.Airport)
+	if !ok {
+		return arrival
+	}
+
+	if arrival.City == "" {
+		arrival.City = a.City
+	}
+	arrival.AirportName = a.Name
+	arrival.Country = a.Country
+	arrival.Timezone = a.Timezone
+
+	return arrival
 }
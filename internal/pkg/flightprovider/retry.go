@@ -0,0 +1,163 @@
+package flightprovider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+)
+
+// default backoff of the retry policy, 200ms * 2^attempt
+const (
+	DefaultBaseDelay  = 200 * time.Millisecond
+	DefaultMultiplier = 2.0
+)
+
+// Backoff is the delay before each retry, BaseDelay * Multiplier^attempt capped at MaxDelay.
+// Jitter randomizes the delay by up to that fraction so providers aren't retried in lockstep
+type Backoff struct {
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+// Delay returns the delay before retrying after the attempt, the first attempt is 0
+func (b Backoff) Delay(attempt int) time.Duration {
+	baseDelay := b.BaseDelay
+	if baseDelay <= 0 {
+		baseDelay = DefaultBaseDelay
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultMultiplier
+	}
+
+	delay := float64(baseDelay) * math.Pow(multiplier, float64(attempt))
+	if b.MaxDelay > 0 && delay > float64(b.MaxDelay) {
+		delay = float64(b.MaxDelay)
+	}
+
+	if b.Jitter > 0 {
+		jitter := math.Min(b.Jitter, 1)
+		delay *= 1 + jitter*(2*rand.Float64()-1)
+	}
+
+	return time.Duration(delay)
+}
+
+// RetryBudget caps the retries to a ratio of the calls, so a failing provider isn't
+// called MaxRetries times more than its traffic. every call deposits Ratio and every
+// retry withdraws 1 from a balance capped at Capacity, which is also the starting balance
+type RetryBudget struct {
+	mu       sync.Mutex
+	ratio    float64
+	capacity float64
+	balance  float64
+}
+
+func NewRetryBudget(ratio float64, capacity int) *RetryBudget {
+	return &RetryBudget{
+		ratio:    ratio,
+		capacity: float64(capacity),
+		balance:  float64(capacity),
+	}
+}
+
+func (b *RetryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.balance = math.Min(b.capacity, b.balance+b.ratio)
+}
+
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.balance < 1 {
+		return false
+	}
+
+	b.balance--
+	return true
+}
+
+// RetryPolicy retries the retryable errors of a provider call with backoff,
+// a nil Budget doesn't limit the retries
+type RetryPolicy struct {
+	Name       string
+	MaxRetries int
+	Backoff    Backoff
+	Budget     *RetryBudget
+	Retryable  func(err error) bool
+}
+
+// NewRetryPolicy returns the retry policy of the provider, timeouts and server errors
+// of the provider are retried and rejected requests are not
+func NewRetryPolicy(name string, config FlightProviderConfig) *RetryPolicy {
+	return &RetryPolicy{
+		Name:       name,
+		MaxRetries: config.MaxRetries,
+		Backoff:    config.Backoff,
+		Budget:     config.RetryBudget,
+		Retryable:  providerutils.IsRetryable,
+	}
+}
+
+// Do calls fn until it succeeds, fails with an error that isn't retryable, runs out of
+// retries or budget, or ctx is done. the last error of fn is wrapped in the returned error
+func (p *RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.Budget != nil {
+		p.Budget.deposit()
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= p.MaxRetries; attempt++ {
+		lastErr = fn(ctx)
+		if lastErr == nil {
+			return nil
+		}
+
+		if !p.retryable(lastErr) {
+			return lastErr
+		}
+
+		slog.ErrorContext(ctx, "failed to call flight search API", "provider", p.Name,
+			"attempt", attempt+1, "error", lastErr)
+
+		if attempt == p.MaxRetries {
+			break
+		}
+
+		if p.Budget != nil && !p.Budget.withdraw() {
+			return fmt.Errorf("%w: retry budget exhausted: %w", providerutils.ErrRetryExceeded, lastErr)
+		}
+
+		backoff := p.Backoff.Delay(attempt)
+		slog.InfoContext(ctx, "retrying with exponential backoff", "provider", p.Name,
+			"backoff", backoff, "next_attempt", attempt+2)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled or timeout: %w: %w", ctx.Err(), lastErr)
+		}
+	}
+
+	return fmt.Errorf("%w after %d attempts: %w", providerutils.ErrRetryExceeded, p.MaxRetries+1, lastErr)
+}
+
+func (p *RetryPolicy) retryable(err error) bool {
+	if p.Retryable == nil {
+		return providerutils.IsRetryable(err)
+	}
+
+	return p.Retryable(err)
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package flightprovider
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/flightprovider/providerutils"
+)
+
+func TestRetryPolicy_Do_Closure(t *testing.T) {
+	backoff := Backoff{BaseDelay: time.Millisecond}
+
+	doRequest := func(policy *RetryPolicy, errs []error, wantCalls int, wantErrs []error) func(t *testing.T) {
+		return func(t *testing.T) {
+			calls := 0
+			err := policy.Do(context.Background(), func(ctx context.Context) error {
+				calls++
+				if calls > len(errs) {
+					return nil
+				}
+				return errs[calls-1]
+			})
+
+			if diff := cmp.Diff(wantCalls, calls); diff != "" {
+				t.Fatalf("RetryPolicy.Do calls mismatch (-want +got):\n%s", diff)
+			}
+
+			if len(wantErrs) == 0 && err != nil {
+				t.Fatalf("RetryPolicy.Do unexpected error: %v", err)
+			}
+
+			for _, wantErr := range wantErrs {
+				if !errors.Is(err, wantErr) {
+					t.Fatalf("RetryPolicy.Do error %v doesn't wrap %v", err, wantErr)
+				}
+			}
+		}
+	}
+
+	t.Run("succeeds_after_retry", doRequest(
+		&RetryPolicy{MaxRetries: 3, Backoff: backoff},
+		[]error{providerutils.ErrProviderInternalError},
+		2, nil,
+	))
+
+	t.Run("rejected_request_is_not_retried", doRequest(
+		&RetryPolicy{MaxRetries: 3, Backoff: backoff},
+		[]error{providerutils.ErrProviderBadRequest},
+		1, []error{providerutils.ErrProviderBadRequest},
+	))
+
+	t.Run("out_of_retries_wraps_last_error", doRequest(
+		&RetryPolicy{MaxRetries: 2, Backoff: backoff},
+		[]error{
+			providerutils.ErrProviderInternalError,
+			providerutils.ErrProviderInternalError,
+			providerutils.ErrProviderInternalError,
+		},
+		3, []error{providerutils.ErrRetryExceeded, providerutils.ErrProviderInternalError},
+	))
+
+	t.Run("out_of_budget", doRequest(
+		&RetryPolicy{MaxRetries: 3, Backoff: backoff, Budget: NewRetryBudget(0, 1)},
+		[]error{
+			providerutils.ErrProviderInternalError,
+			providerutils.ErrProviderInternalError,
+			providerutils.ErrProviderInternalError,
+		},
+		2, []error{providerutils.ErrRetryExceeded, providerutils.ErrProviderInternalError},
+	))
+}
+
+func TestBackoff_Delay_Closure(t *testing.T) {
+	delayRequest := func(backoff Backoff, attempt int, want time.Duration) func(t *testing.T) {
+		return func(t *testing.T) {
+			got := backoff.Delay(attempt)
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("Backoff.Delay mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("default_first_attempt", delayRequest(Backoff{}, 0, 200*time.Millisecond))
+	t.Run("default_exponential", delayRequest(Backoff{}, 2, 800*time.Millisecond))
+	t.Run("capped_at_max_delay", delayRequest(Backoff{MaxDelay: time.Second}, 5, time.Second))
+	t.Run("custom_multiplier", delayRequest(
+		Backoff{BaseDelay: 100 * time.Millisecond, Multiplier: 3}, 2, 900*time.Millisecond))
+
+	t.Run("jitter_within_range", func(t *testing.T) {
+		backoff := Backoff{BaseDelay: 100 * time.Millisecond, Jitter: 0.5}
+		for i := 0; i < 100; i++ {
+			got := backoff.Delay(0)
+			if got < 50*time.Millisecond || got > 150*time.Millisecond {
+				t.Fatalf("Backoff.Delay with jitter out of range: %s", got)
+			}
+		}
+	})
+}
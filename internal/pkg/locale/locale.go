@@ -0,0 +1,166 @@
+package locale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/currency"
+)
+
+// supported locale tags
+const (
+	Indonesian      = "id-ID"
+	EnglishUS       = "en-US"
+	EnglishSG       = "en-SG"
+	MalayMY         = "ms-MY"
+	defaultLanguage = "en"
+)
+
+// Locale formats prices and durations for one language and region
+type Locale struct {
+	Tag       string
+	thousands string
+	point     string
+	// symbols overrides the currency symbols where the local currency has its own
+	symbols map[string]string
+	hour    string
+	minute  string
+	// spaced puts a space between a number and its unit, e.g. "2 jam" instead of "2h"
+	spaced bool
+}
+
+var locales = map[string]Locale{
+	Indonesian: {
+		Tag:       Indonesian,
+		thousands: ".",
+		point:     ",",
+		hour:      "jam",
+		minute:    "menit",
+		spaced:    true,
+	},
+	EnglishUS: {
+		Tag:       EnglishUS,
+		thousands: ",",
+		point:     ".",
+		symbols:   map[string]string{"USD": "$"},
+		hour:      "h",
+		minute:    "m",
+	},
+	EnglishSG: {
+		Tag:       EnglishSG,
+		thousands: ",",
+		point:     ".",
+		symbols:   map[string]string{"SGD": "$"},
+		hour:      "h",
+		minute:    "m",
+	},
+	MalayMY: {
+		Tag:       MalayMY,
+		thousands: ",",
+		point:     ".",
+		hour:      "jam",
+		minute:    "minit",
+		spaced:    true,
+	},
+}
+
+// languages picks the locale of a bare language tag like "id" or "en-GB"
+var languages = map[string]string{
+	"id":            Indonesian,
+	"in":            Indonesian,
+	"ms":            MalayMY,
+	defaultLanguage: EnglishUS,
+}
+
+// Lookup returns a supported locale, tags are matched case-insensitively
+func Lookup(tag string) (Locale, bool) {
+	for key, l := range locales {
+		if strings.EqualFold(key, tag) {
+			return l, true
+		}
+	}
+
+	return Locale{}, false
+}
+
+// Negotiate picks the supported locale preferred by an Accept-Language header,
+// an unsupported region falls back to the language, empty when nothing matches
+func Negotiate(acceptLanguage string) string {
+	best, bestQuality := "", 0.0
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		if quality <= bestQuality {
+			continue
+		}
+
+		if l, ok := Lookup(tag); ok {
+			best, bestQuality = l.Tag, quality
+			continue
+		}
+
+		language, _, _ := strings.Cut(tag, "-")
+		if supported, ok := languages[strings.ToLower(language)]; ok {
+			best, bestQuality = supported, quality
+		}
+	}
+
+	return best
+}
+
+// FormatPrice formats an amount with the symbol and separators of the locale,
+// e.g. Rp1.234.567 for id-ID or Rp1,234,567 for en-US
+func (l Locale) FormatPrice(amount float64, code string) string {
+	code = strings.ToUpper(code)
+	if code == "" {
+		code = currency.DefaultCurrency
+	}
+
+	symbol, ok := l.symbols[code]
+	if !ok {
+		symbol = currency.Symbol(code)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+	}
+
+	return symbol + sign + currency.FormatAmount(amount, code, l.thousands, l.point)
+}
+
+// FormatDuration formats minutes with the unit words of the locale,
+// e.g. "2h 5m" for en-US or "2 jam 5 menit" for id-ID
+func (l Locale) FormatDuration(minutes int) string {
+	h := minutes / 60
+	m := minutes % 60
+
+	switch {
+	case m == 0:
+		return l.unit(h, l.hour)
+	case h == 0:
+		return l.unit(m, l.minute)
+	}
+
+	return l.unit(h, l.hour) + " " + l.unit(m, l.minute)
+}
+
+func (l Locale) unit(value int, word string) string {
+	if l.spaced {
+		return fmt.Sprintf("%d %s", value, word)
+	}
+
+	return fmt.Sprintf("%d%s", value, word)
+}
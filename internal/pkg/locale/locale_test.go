@@ -0,0 +1,68 @@
+//go:build unit
+
+package locale
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocale_FormatPrice(t *testing.T) {
+	formatRequest := func(tag string, amount float64, code, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			l, ok := Lookup(tag)
+			if !ok {
+				t.Fatalf("Lookup(%q) not found", tag)
+			}
+
+			if diff := cmp.Diff(want, l.FormatPrice(amount, code)); diff != "" {
+				t.Fatalf("FormatPrice() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("id_rupiah", formatRequest(Indonesian, 1234567, "IDR", "Rp1.234.567"))
+	t.Run("id_singapore_dollar", formatRequest(Indonesian, 1234.5, "SGD", "S$1.234,50"))
+	t.Run("en_us_rupiah", formatRequest(EnglishUS, 1234567, "IDR", "Rp1,234,567"))
+	t.Run("en_us_dollar", formatRequest(EnglishUS, 99.9, "USD", "$99.90"))
+	t.Run("en_sg_local_dollar", formatRequest(EnglishSG, 1234.5, "SGD", "$1,234.50"))
+	t.Run("en_sg_us_dollar", formatRequest(EnglishSG, 10, "USD", "US$10.00"))
+	t.Run("ms_ringgit", formatRequest(MalayMY, 250, "MYR", "RM250.00"))
+	t.Run("missing_currency_is_rupiah", formatRequest(MalayMY, 1000, "", "Rp1,000"))
+}
+
+func TestLocale_FormatDuration(t *testing.T) {
+	formatRequest := func(tag string, minutes int, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			l, _ := Lookup(tag)
+
+			if diff := cmp.Diff(want, l.FormatDuration(minutes)); diff != "" {
+				t.Fatalf("FormatDuration() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("en_hours_and_minutes", formatRequest(EnglishUS, 125, "2h 5m"))
+	t.Run("id_hours_and_minutes", formatRequest(Indonesian, 125, "2 jam 5 menit"))
+	t.Run("ms_minutes_only", formatRequest(MalayMY, 45, "45 minit"))
+	t.Run("id_hours_only", formatRequest(Indonesian, 120, "2 jam"))
+}
+
+func TestNegotiate(t *testing.T) {
+	negotiateRequest := func(header, want string) func(t *testing.T) {
+		return func(t *testing.T) {
+			if diff := cmp.Diff(want, Negotiate(header)); diff != "" {
+				t.Fatalf("Negotiate() mismatch (-want +got):\n%s", diff)
+			}
+		}
+	}
+
+	t.Run("exact_match", negotiateRequest("en-SG", EnglishSG))
+	t.Run("case_insensitive", negotiateRequest("id-id", Indonesian))
+	t.Run("highest_quality", negotiateRequest("en-US;q=0.5, ms-MY;q=0.9", MalayMY))
+	t.Run("language_fallback", negotiateRequest("fr-FR, en-GB;q=0.7", EnglishUS))
+	t.Run("bare_indonesian", negotiateRequest("id", Indonesian))
+	t.Run("unsupported", negotiateRequest("fr-FR, de", ""))
+	t.Run("empty", negotiateRequest("", ""))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
@@ -23,6 +24,64 @@ func ResponseWithBody(_ context.Context, w http.ResponseWriter, response interfa
 	return nil
 }
 
+// AcceptedResponse encodes the response of a request that is still processed in the background.
+func AcceptedResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		return fmt.Errorf("encode response body: %w", err)
+	}
+
+	return nil
+}
+
+// ResponseWithEventStream writes every search event of the channel as a server-sent event
+// and flushes it right away, it returns when the channel is closed or the client disconnects.
+func ResponseWithEventStream(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	events, ok := response.(<-chan dto.SearchStreamEvent)
+	if !ok {
+		return errors.New("encode event stream: invalid type")
+	}
+
+	// the search deadline of the request may be longer than the write timeout of the
+	// server, the stream ends with the search or when the client disconnects
+	controller := http.NewResponseController(w)
+	if err := controller.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("clear event stream write deadline: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // don't let a proxy buffer the stream
+	w.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, open := <-events:
+			if !open {
+				return nil
+			}
+
+			data, err := json.Marshal(event.Data)
+			if err != nil {
+				return fmt.Errorf("encode event %s: %w", event.Event, err)
+			}
+
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Event, data); err != nil {
+				return fmt.Errorf("write event %s: %w", event.Event, err)
+			}
+
+			if err := controller.Flush(); err != nil {
+				return fmt.Errorf("flush event %s: %w", event.Event, err)
+			}
+		}
+	}
+}
+
 func NoContentResponse(_ context.Context, w http.ResponseWriter, _ interface{}) error {
 	w.WriteHeader(http.StatusNoContent)
 
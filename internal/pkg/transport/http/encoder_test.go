@@ -5,10 +5,13 @@ package http
 import (
 	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/ijalalfrz/flight-search-aggregation-service/internal/app/dto"
 	"github.com/ijalalfrz/flight-search-aggregation-service/internal/pkg/exception"
 	"github.com/stretchr/testify/assert"
 )
@@ -43,6 +46,60 @@ func TestEncodeJSONResponse(t *testing.T) {
 	assert.JSONEq(t, `{"foo": "bar"}`, resp.Body.String())
 }
 
+func TestAcceptedResponse(t *testing.T) {
+	resp := httptest.NewRecorder()
+	err := AcceptedResponse(context.Background(), resp, map[string]string{"id": "foo"})
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, "application/json; charset=utf-8", resp.Result().Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"id": "foo"}`, resp.Body.String())
+}
+
+func TestEventStreamResponse(t *testing.T) {
+	events := make(chan dto.SearchStreamEvent, 2)
+	events <- dto.SearchStreamEvent{Event: "provider", Data: map[string]string{"provider": "foo"}}
+	events <- dto.SearchStreamEvent{Event: "result", Data: map[string]int{"total": 1}}
+	close(events)
+
+	resp := httptest.NewRecorder()
+	err := ResponseWithEventStream(context.Background(), resp, (<-chan dto.SearchStreamEvent)(events))
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "text/event-stream", resp.Result().Header.Get("Content-Type"))
+	assert.Equal(t, "event: provider\ndata: {\"provider\":\"foo\"}\n\n"+
+		"event: result\ndata: {\"total\":1}\n\n", resp.Body.String())
+	assert.True(t, resp.Flushed)
+}
+
+func TestEventStreamResponse_OutlivesWriteTimeout(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		events := make(chan dto.SearchStreamEvent)
+		go func() {
+			defer close(events)
+			// the result comes after the write timeout of the server
+			time.Sleep(300 * time.Millisecond)
+			events <- dto.SearchStreamEvent{Event: "result", Data: map[string]int{"total": 1}}
+		}()
+
+		_ = ResponseWithEventStream(r.Context(), w, (<-chan dto.SearchStreamEvent)(events))
+	}))
+	server.Config.WriteTimeout = 100 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to open the event stream: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "event: result\ndata: {\"total\":1}\n\n", string(body))
+}
+
 func TestNoContentResponse(t *testing.T) {
 	resp := httptest.NewRecorder()
 	err := NoContentResponse(context.Background(), resp, nil)
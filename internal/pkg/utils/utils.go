@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // ConvertMinutesToDuration convert minutes to duration format string
@@ -29,13 +31,15 @@ func ConvertHourToDuration(durationInHours float64) string {
 	return ConvertMinutesToDuration(int64(durationInHours * 60))
 }
 
-// ConvertDurationToMinutes convert duration format string to minutes
-// Example: "2h 30m" -> 150
+// ConvertDurationToMinutes convert duration format string to minutes, either part may be left out
+// Example: "2h 30m" -> 150, "2h" -> 120, "55m" -> 55
 func ConvertDurationToMinutes(duration string) int64 {
-	var h, m int64
-	fmt.Sscanf(duration, "%dh %dm", &h, &m)
+	d, err := time.ParseDuration(strings.ReplaceAll(duration, " ", ""))
+	if err != nil {
+		return 0
+	}
 
-	return h*60 + m
+	return int64(d.Minutes())
 }
 
 func FormatRupiah(amount int64) string {